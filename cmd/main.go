@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
-	"log"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,19 +13,28 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/audit"
 	"github.com/samoray1998/fintech-wallet/internal/config"
 	"github.com/samoray1998/fintech-wallet/internal/controllers"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/logging"
+	"github.com/samoray1998/fintech-wallet/internal/mailer"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
 	"github.com/samoray1998/fintech-wallet/internal/middlewares"
+	"github.com/samoray1998/fintech-wallet/internal/migrations"
 	"github.com/samoray1998/fintech-wallet/internal/repositories"
 	"github.com/samoray1998/fintech-wallet/internal/routes"
 	"github.com/samoray1998/fintech-wallet/internal/services"
+	"github.com/samoray1998/fintech-wallet/internal/storage"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 func main() {
 
 	cfg := config.LoadConfig()
+	slog.SetDefault(logging.New(os.Stdout, cfg.Server.Debug))
 
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -30,8 +42,17 @@ func main() {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	// /metrics carries business figures such as signups and transfer volume
+	if cfg.Server.MetricsToken == "" && !cfg.Server.Debug {
+		fatal("Refusing to serve /metrics without a token", errors.New("set METRICS_TOKEN, or DEBUG for local use"))
+	}
+
 	///Initialize MongoDB client with configured timeouts
-	mongoOptions := options.Client().ApplyURI(cfg.Database.Uri).SetMaxPoolSize(cfg.Database.MaxPoolSize).SetMinPoolSize(cfg.Database.MinPoolSize).SetConnectTimeout(cfg.Database.ConnectTimeout).SetSocketTimeout(cfg.Database.SocketTimeout)
+	walletMetrics := metrics.New()
+	poolMonitor := metrics.NewMongoPoolMonitor()
+	walletMetrics.Register(poolMonitor)
+
+	mongoOptions := options.Client().ApplyURI(cfg.Database.Uri).SetMaxPoolSize(cfg.Database.MaxPoolSize).SetMinPoolSize(cfg.Database.MinPoolSize).SetConnectTimeout(cfg.Database.ConnectTimeout).SetSocketTimeout(cfg.Database.SocketTimeout).SetPoolMonitor(poolMonitor.Monitor())
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.TimeOut)
 	defer cancel()
@@ -39,43 +60,144 @@ func main() {
 	client, err := mongo.Connect(ctx, mongoOptions)
 
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		fatal("Failed to connect to MongoDB", err)
 	}
 
 	defer func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("Failed to disconnect MongoDB: %v", err)
+			slog.Error("Failed to disconnect MongoDB", "error", err)
 		}
 	}()
 	/// Verify connection
 
 	err = client.Ping(ctx, nil)
 	if err != nil {
-		log.Fatalf("Failed to ping MongoDB: %v", err)
+		fatal("Failed to ping MongoDB", err)
 	}
-	log.Println("Successfully connected to MongoDB")
+	slog.Info("Successfully connected to MongoDB")
 
 	db := client.Database(cfg.Database.Name)
+	warnPendingMigrations(ctx, db)
 
 	/// Initialize repositories
 
 	userRepo := repositories.NewUserRepo(db, "users")
+	accountRepo := repositories.NewAccountRepo(db, "accounts")
+	transactionRepo := repositories.NewTransactionRepo(db, "transactions")
+	walletLedger := ledger.NewLedger(db, "journal_entries", "accounts")
+	fxQuoteRepo := repositories.NewFXQuoteRepo(db, "fx_quotes")
+	tokenRepo := repositories.NewTokenRepo(db, "refresh_tokens")
+	revocationRepo := repositories.NewRevocationRepo(db, "revoked_tokens")
+	passwordResetRepo := repositories.NewPasswordResetRepo(db, "password_resets")
+	loginAttemptRepo := repositories.NewLoginAttemptRepo(db, "login_attempts")
+	adminActionRepo := repositories.NewAdminActionRepo(db, "admin_actions")
+	auditStore := audit.NewMongoStore(db, "audit_events")
+	auditLog := audit.NewLogger(auditStore)
+	transactor := repositories.NewTransactor(client)
+	idempotencyRepo := repositories.NewIdempotencyRepo(db, "idempotency_keys", cfg.Server.IdempotencyTTL)
 
 	/// Initialize services
-	userService := services.NewUserService(*userRepo, cfg.Auth.BcryptCost)
-	authService := services.NewAuthService(*userRepo, cfg.Auth.JWTSecret, cfg.Auth.JWTAccessExpiry)
+	userService := services.NewUserService(userRepo, walletMetrics, cfg.Auth.BcryptCost)
+	revocationService := services.NewRevocationService(revocationRepo, cfg.Auth.RevocationCacheTTL)
+	authService := services.NewAuthService(userRepo, tokenRepo, revocationService, cfg.Auth.JWTSecret, cfg.Auth.JWTAccessExpiry, cfg.Auth.JWTRefreshExpiry, cfg.Auth.ChallengeExpiry)
+	kycTiers, err := services.LoadKYCTiers(cfg.KYC.TiersFile)
+	if err != nil {
+		fatal("Failed to load KYC tiers", err, "file", cfg.KYC.TiersFile)
+	}
+	limitService := services.NewLimitService(userRepo, transactionRepo, kycTiers)
+	accountService := services.NewAccountService(accountRepo, walletLedger, limitService)
+	var mail mailer.Mailer
+	switch cfg.Mail.Driver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	case "file":
+		mail = mailer.NewFileMailer(cfg.Mail.FilePath, cfg.Mail.From)
+	default:
+		mail = mailer.NewLogMailer()
+	}
+	emailVerificationService := services.NewEmailVerificationService(userRepo, authService, mail, cfg.Server.PublicURL, cfg.Auth.EmailVerifyExpiry, cfg.Auth.RequireVerified)
+	loginProtectionService := services.NewLoginProtectionService(loginAttemptRepo, userService, mail, auditLog, walletMetrics, services.LoginPolicy{
+		FailureWindow:      cfg.Auth.LoginFailureWindow,
+		BackoffAfter:       cfg.Auth.LoginBackoffAfter,
+		BackoffBase:        cfg.Auth.LoginBackoffBase,
+		BackoffMax:         cfg.Auth.LoginBackoffMax,
+		LockoutThreshold:   cfg.Auth.LockoutThreshold,
+		LockoutDuration:    cfg.Auth.LockoutDuration,
+		IPLockoutThreshold: cfg.Auth.IPLockoutThreshold,
+	})
+	twoFactorService := services.NewTwoFactorService(userRepo, loginProtectionService, cfg.Auth.TwoFactorIssuer)
+	kycProvider := newKYCProvider(cfg)
+	kycCaseRepo := repositories.NewKYCCaseRepo(db, "kyc_cases")
+	kycService := services.NewKYCService(userRepo, kycCaseRepo, kycProvider, transactor, auditLog, walletMetrics, cfg.KYC.WebhookSecret)
+	kycDocumentService := services.NewKYCDocumentService(kycCaseRepo, userRepo, newDocumentStore(db, cfg.KYC), cfg.KYC.MaxDocumentBytes)
+	passwordResetService := services.NewPasswordResetService(passwordResetRepo, userService, authService, mail, auditLog, cfg.Server.PublicURL, cfg.Auth.ResetTokenExpiry, cfg.Auth.ResetMaxPerHour)
+	rateService := services.NewRateService(newRateProvider(cfg), cfg.Rates.BaseCurrency, cfg.Rates.CacheDuration, cfg.Rates.MaxAge)
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo, userRepo, walletLedger, transactor, limitService, auditLog, walletMetrics)
+	fxService := services.NewFXService(fxQuoteRepo, accountRepo, transactionRepo, rateService, walletLedger, transactor, limitService, auditLog, walletMetrics, cfg.Rates.FXSpreadBps, cfg.Rates.FXQuoteTTL, cfg.Rates.FXMaxRateAge)
+	healthService := services.NewHealthService(cfg.Health.CacheFor,
+		services.HealthCheck{Name: "mongo", Timeout: cfg.Health.MongoTimeout, Critical: true, Check: func(ctx context.Context) error {
+			return client.Ping(ctx, readpref.Primary())
+		}},
+		services.HealthCheck{Name: "rates", Timeout: cfg.Health.RatesTimeout, Check: services.RatesFreshnessCheck(rateService, cfg.Health.RatesMaxAge)},
+		services.HealthCheck{Name: "kyc_provider", Timeout: cfg.Health.KYCTimeout, Check: kycProvider.Ping},
+	)
+	adminService := services.NewAdminService(userRepo, accountRepo, transactionRepo, adminActionRepo, kycService, transactor, auditLog)
+	if cfg.Auth.BootstrapAdmin != "" {
+		if err := adminService.BootstrapAdmin(context.Background(), cfg.Auth.BootstrapAdmin); err != nil {
+			slog.Error("Failed to promote bootstrap admin", "error", err)
+		}
+	}
 
 	// Initialize controllers
 	userController := controllers.NewUserController(userService)
-	authController := controllers.NewAuthController(authService, userService)
-	authMiddleware := middlewares.NewAuthMiddleware(authService)
+	authController := controllers.NewAuthController(authService, userService, twoFactorService, emailVerificationService, loginProtectionService)
+	accountController := controllers.NewAccountController(accountService)
+	transactionController := controllers.NewTransactionController(transactionService)
+	rateController := controllers.NewRateController(rateService)
+	fxController := controllers.NewFXController(fxService)
+	passwordController := controllers.NewPasswordController(passwordResetService)
+	adminController := controllers.NewAdminController(adminService, kycService, kycDocumentService, loginProtectionService)
+	kycController := controllers.NewKYCController(kycService, kycDocumentService, cfg.KYC.MaxDocumentBytes)
+	healthController := controllers.NewHealthController(healthService, cfg.Server.MetricsToken)
+	authMiddleware := middlewares.NewAuthMiddleware(authService, twoFactorService, emailVerificationService)
+	idempotencyMiddleware := middlewares.NewIdempotencyMiddleware(idempotencyRepo)
+	adminAuditMiddleware := middlewares.NewAdminAuditMiddleware(adminService)
+	metricsMiddleware := middlewares.NewMetricsMiddleware(walletMetrics, cfg.Server.MetricsToken)
+
+	var rateLimitStore middlewares.RateLimitStore
+	if cfg.Server.RateLimitStore == "mongo" {
+		rateLimitStore = repositories.NewRateLimitRepo(db, "rate_limits")
+	} else {
+		rateLimitStore = middlewares.NewMemoryRateLimitStore()
+	}
+	rateLimiter := middlewares.NewRateLimiter(rateLimitStore)
+	rateLimits := middlewares.RateLimitPolicies{
+		Default: middlewares.RateLimitPolicy{Name: "default", Requests: cfg.Server.RateLimit, Per: time.Minute, Burst: cfg.Server.RateLimit},
+		Auth:    middlewares.RateLimitPolicy{Name: "auth", Requests: cfg.Server.AuthRateLimit, Per: time.Minute, Burst: cfg.Server.AuthRateLimit},
+	}
 
-	router := routes.SetupRouter(authMiddleware,
+	router, err := routes.SetupRouter(authMiddleware,
+		adminAuditMiddleware,
+		metricsMiddleware,
+		rateLimiter,
+		idempotencyMiddleware,
 		authController,
 		userController,
-		cfg.Server.RateLimit)
+		accountController,
+		transactionController,
+		rateController,
+		fxController,
+		passwordController,
+		adminController,
+		kycController,
+		healthController,
+		rateLimits,
+		cfg.Server.TrustedProxies)
+	if err != nil {
+		fatal("Invalid TRUSTED_PROXIES", err)
+	}
 
 	// Configure HTTP server
 	server := &http.Server{
@@ -91,15 +213,19 @@ func main() {
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Starting server on port %s in %s mode", cfg.Server.Port, cfg.Server.Env)
+		slog.Info("Starting server", "port", cfg.Server.Port, "env", cfg.Server.Env)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+			fatal("Server failed", err)
 		}
 	}()
 
 	// Wait for interrupt signal
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server...")
+
+	// Fail readiness first and give load balancers time to stop sending traffic
+	healthService.Drain()
+	time.Sleep(cfg.Health.ShutdownDrain)
 
 	// Context with timeout for shutdown
 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
@@ -107,9 +233,104 @@ func main() {
 
 	// Attempt graceful shutdown
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+		slog.Error("Server shutdown error", "error", err)
 	}
+	passwordResetService.Wait()
 
 	// Additional cleanup if needed
-	log.Println("Server exited properly")
+	slog.Info("Server exited properly")
+}
+
+// newKYCProvider picks the identity provider. The fake one approves whatever
+// KYC_FAKE_OUTCOME says, so it only runs in debug mode or with KYC_ALLOW_FAKE set.
+func newKYCProvider(cfg *config.Config) services.KYCProvider {
+	switch cfg.KYC.Provider {
+	case "http":
+		return services.NewHTTPKYCProvider(cfg.KYC.VerifyURL, cfg.KYC.APIKey, cfg.KYC.APITimeout, cfg.KYC.MaxRetries)
+	case "fake":
+		if !cfg.Server.Debug && !cfg.KYC.AllowFake {
+			fatal("Refusing to start with the fake KYC provider", errors.New("set DEBUG or KYC_ALLOW_FAKE to use it"))
+		}
+		slog.Warn("Using the fake KYC provider, identities are not checked", "outcome", cfg.KYC.FakeOutcome)
+		return services.NewFakeKYCProvider(cfg.KYC.FakeOutcome)
+	default:
+		fatal("Invalid KYC_PROVIDER", fmt.Errorf("%q is not \"http\" or \"fake\"", cfg.KYC.Provider))
+		return nil
+	}
+}
+
+// newRateProvider picks where exchange rates come from. The static provider serves
+// whatever RATES_FILE holds, so it only runs in debug mode or with RATES_ALLOW_STATIC set.
+func newRateProvider(cfg *config.Config) services.RateProvider {
+	switch cfg.Rates.Provider {
+	case "http":
+		return services.NewHTTPRateProvider(cfg.Rates.ExchangeAPIURL, cfg.Rates.APIKey, cfg.Rates.APITimeout)
+	case "static":
+		if !cfg.Server.Debug && !cfg.Rates.AllowStatic {
+			fatal("Refusing to start with static exchange rates", errors.New("set DEBUG or RATES_ALLOW_STATIC to use them"))
+		}
+		slog.Warn("Using static exchange rates, conversions are not priced from the market", "file", cfg.Rates.StaticFile)
+		return services.NewStaticRateProvider(cfg.Rates.StaticFile)
+	default:
+		fatal("Invalid RATES_PROVIDER", fmt.Errorf("%q is not \"http\" or \"static\"", cfg.Rates.Provider))
+		return nil
+	}
+}
+
+// newDocumentStore builds the encrypted blob store for KYC documents. Without an
+// encryption key it returns nil and uploads are refused rather than stored in plaintext.
+func newDocumentStore(db *mongo.Database, cfg config.KYCConfig) storage.BlobStore {
+	if cfg.EncryptionKey == "" {
+		slog.Warn("KYC_ENCRYPTION_KEY not set, KYC document uploads are disabled")
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+	if err != nil {
+		fatal("Invalid KYC_ENCRYPTION_KEY", err)
+	}
+
+	var backend storage.BlobStore
+	if cfg.StorageBackend == "gridfs" {
+		backend, err = storage.NewGridFSBlobStore(db, "kyc_documents")
+	} else {
+		backend, err = storage.NewDiskBlobStore(cfg.StoragePath)
+	}
+	if err != nil {
+		fatal("Failed to initialise KYC document storage", err)
+	}
+
+	store, err := storage.NewEncryptedBlobStore(backend, key)
+	if err != nil {
+		fatal("Invalid KYC_ENCRYPTION_KEY", err)
+	}
+	return store
+}
+
+// warnPendingMigrations logs migrations that have not been applied. The server still
+// starts; indexes and validators are managed by cmd/migrate, not at startup.
+func warnPendingMigrations(ctx context.Context, db *mongo.Database) {
+	migrator, err := migrations.NewMigrator(db, migrations.Collection, migrations.All)
+	if err != nil {
+		fatal("Invalid migrations", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		slog.Error("Failed to check migrations", "error", err)
+		return
+	}
+	pending := []int{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Version)
+		}
+	}
+	if len(pending) > 0 {
+		slog.Warn("Database has pending migrations, run: go run ./cmd/migrate up", "versions", pending)
+	}
+}
+
+// fatal logs err and exits; deferred cleanup does not run, as with log.Fatal.
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append(args, "error", err)...)
+	os.Exit(1)
 }
@@ -0,0 +1,135 @@
+// Command migrate applies, reverts and lists the MongoDB schema migrations. It reads the
+// same environment as the server for the database to use.
+//
+//	migrate status          list migrations and when each was applied
+//	migrate up [version]    apply pending migrations, up to version if given
+//	migrate down [steps]    revert the last applied migration, or the last steps of them
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/config"
+	"github.com/samoray1998/fintech-wallet/internal/logging"
+	"github.com/samoray1998/fintech-wallet/internal/migrations"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const usage = `usage: migrate <command> [arg]
+
+commands:
+  status          list migrations and when each was applied
+  up [version]    apply pending migrations, up to version if given
+  down [steps]    revert the last applied migration, or the last steps of them
+`
+
+func main() {
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	arg := 0
+	if len(os.Args) == 3 {
+		n, err := strconv.Atoi(os.Args[2])
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "%s: %q is not a positive number\n\n%s", command, os.Args[2], usage)
+			os.Exit(2)
+		}
+		arg = n
+	}
+
+	cfg := config.LoadConfig()
+	slog.SetDefault(logging.New(os.Stderr, cfg.Server.Debug))
+
+	// index builds on large collections can take a while, so no overall deadline
+	ctx := context.Background()
+	connectCtx, cancel := context.WithTimeout(ctx, cfg.Database.ConnectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.Database.Uri).SetConnectTimeout(cfg.Database.ConnectTimeout))
+	if err != nil {
+		fatal("Failed to connect to MongoDB", err)
+	}
+	defer client.Disconnect(context.Background())
+	if err := client.Ping(connectCtx, nil); err != nil {
+		fatal("Failed to ping MongoDB", err)
+	}
+
+	db := client.Database(cfg.Database.Name)
+	migrator, err := migrations.NewMigrator(db, migrations.Collection, migrations.All)
+	if err != nil {
+		fatal("Invalid migrations", err)
+	}
+
+	switch command {
+	case "status":
+		printStatus(ctx, migrator)
+	case "up":
+		applied, err := migrator.Up(ctx, arg)
+		report("Applied migration", applied)
+		if err != nil {
+			fatal("Migration failed", err)
+		}
+		if len(applied) == 0 {
+			slog.Info("Database is up to date")
+		}
+	case "down":
+		steps := arg
+		if steps == 0 {
+			steps = 1
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		report("Reverted migration", reverted)
+		if err != nil {
+			fatal("Revert failed", err)
+		}
+		if len(reverted) == 0 {
+			slog.Info("No migrations to revert")
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		fatal("Failed to read migrations", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format(time.RFC3339)
+		}
+		description := status.Description
+		if !status.Known {
+			description += " (not in this build)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, applied, description)
+	}
+	w.Flush()
+}
+
+func report(msg string, done []migrations.Migration) {
+	for _, migration := range done {
+		slog.Info(msg, "version", migration.Version, "description", migration.Description)
+	}
+}
+
+// fatal logs err and exits, as in the server.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
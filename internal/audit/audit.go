@@ -0,0 +1,231 @@
+// Package audit keeps a tamper-evident record of security and money events. Events are
+// only ever appended, and each one carries the hash of the one before it, so editing,
+// deleting or reordering history breaks the chain and shows up in Verify.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Event types recorded by the wallet.
+const (
+	EventLoginSucceeded   = "auth.login_succeeded"
+	EventLoginFailed      = "auth.login_failed"
+	EventAccountLocked    = "auth.account_locked"
+	EventPasswordReset    = "auth.password_reset"
+	EventKYCStatusChanged = "kyc.status_changed"
+	EventTransfer         = "money.transfer"
+	EventFXConversion     = "money.fx_conversion"
+	EventAdminAction      = "admin.action"
+)
+
+// Subject types say what SubjectID refers to.
+const (
+	SubjectUser        = "user"
+	SubjectAccount     = "account"
+	SubjectTransaction = "transaction"
+	SubjectKYCCase     = "kyc_case"
+)
+
+// Actors for events not caused by a signed-in user.
+const (
+	ActorSystem    = "system"    // automatic changes, such as a provider webhook
+	ActorAnonymous = "anonymous" // unauthenticated callers, such as a failed login
+)
+
+const (
+	maxAppendAttempts  = 10
+	timestampPrecision = time.Millisecond // what Mongo keeps of a time.Time
+)
+
+// ErrSeqTaken is returned by Store.Append when another writer got that position in the
+// chain first.
+var ErrSeqTaken = errors.New("audit sequence number already taken")
+
+// Event is one link of the chain. Data is restricted to strings so the hash is computed
+// over exactly what comes back from the database.
+type Event struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Seq         int64              `bson:"seq" json:"seq"`
+	Type        string             `bson:"type" json:"type"`
+	ActorID     string             `bson:"actor_id" json:"actor_id"` // user or staff ID, or one of the Actor constants
+	SubjectType string             `bson:"subject_type,omitempty" json:"subject_type,omitempty"`
+	SubjectID   string             `bson:"subject_id,omitempty" json:"subject_id,omitempty"`
+	IP          string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	Data        map[string]string  `bson:"data,omitempty" json:"data,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	PrevHash    string             `bson:"prev_hash" json:"prev_hash"`
+	Hash        string             `bson:"hash" json:"hash"`
+}
+
+// Store persists the chain. Implementations must never update or delete events.
+type Store interface {
+	// Last returns the event with the highest sequence number, or nil for an empty chain.
+	Last(ctx context.Context) (*Event, error)
+	// Append inserts an event, failing with ErrSeqTaken if its Seq is already used.
+	Append(ctx context.Context, event *Event) error
+	// Walk calls fn for every event in sequence order until fn returns an error.
+	Walk(ctx context.Context, fn func(*Event) error) error
+}
+
+// Logger appends events to the chain.
+type Logger struct {
+	store Store
+	mu    sync.Mutex // serialises appends from this process; other instances are caught by ErrSeqTaken
+}
+
+func NewLogger(store Store) *Logger {
+	return &Logger{store: store}
+}
+
+// Record appends an event. Failures are logged, never returned: an audit outage must not
+// stop people from logging in. Money movement calls Append instead, so it has no unaudited
+// path.
+func (l *Logger) Record(ctx context.Context, event Event) {
+	if err := l.Append(ctx, &event); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit event", "type", event.Type, "subject_id", event.SubjectID, "error", err)
+	}
+}
+
+// Append links event to the end of the chain and stores it, filling in ID, Seq,
+// CreatedAt and the hashes. Given the ctx of a Mongo transaction the event commits or
+// rolls back with it. Losing the race for a sequence number has then already aborted the
+// transaction, so rather than retrying on it Append fails with a TransientTransactionError
+// and the driver reruns the whole transaction; outside one it retries here.
+func (l *Logger) Append(ctx context.Context, event *Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if event.ActorID == "" {
+		event.ActorID = ActorSystem
+	}
+	event.CreatedAt = time.Now().UTC().Truncate(timestampPrecision)
+	inTransaction := mongo.SessionFromContext(ctx) != nil
+
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		last, err := l.store.Last(ctx)
+		if err != nil {
+			return err
+		}
+		event.Seq, event.PrevHash = 1, ""
+		if last != nil {
+			event.Seq, event.PrevHash = last.Seq+1, last.Hash
+		}
+		event.ID = primitive.NewObjectID()
+		event.Hash = Hash(event)
+
+		err = l.store.Append(ctx, event)
+		if !errors.Is(err, ErrSeqTaken) {
+			return err
+		}
+		if inTransaction {
+			return retryTransactionError{err}
+		}
+	}
+	return fmt.Errorf("audit chain is too busy: %w", ErrSeqTaken)
+}
+
+// retryTransactionError carries the label that makes mongo.Session.WithTransaction run
+// the transaction again.
+type retryTransactionError struct {
+	err error
+}
+
+var _ mongo.LabeledError = retryTransactionError{}
+
+func (e retryTransactionError) Error() string { return e.err.Error() }
+func (e retryTransactionError) Unwrap() error { return e.err }
+
+func (e retryTransactionError) HasErrorLabel(label string) bool {
+	return label == "TransientTransactionError"
+}
+
+// Hash returns the hex SHA-256 of everything in the event except its ID and its own hash.
+func Hash(event *Event) string {
+	data := event.Data
+	if len(data) == 0 {
+		data = nil // an empty map is not stored, so it must hash like a missing one
+	}
+	canonical, _ := json.Marshal(struct {
+		Seq         int64             `json:"seq"`
+		PrevHash    string            `json:"prev_hash"`
+		Type        string            `json:"type"`
+		ActorID     string            `json:"actor_id"`
+		SubjectType string            `json:"subject_type"`
+		SubjectID   string            `json:"subject_id"`
+		IP          string            `json:"ip"`
+		Data        map[string]string `json:"data"` // encoding/json sorts map keys
+		CreatedAt   int64             `json:"created_at"`
+	}{
+		Seq:         event.Seq,
+		PrevHash:    event.PrevHash,
+		Type:        event.Type,
+		ActorID:     event.ActorID,
+		SubjectType: event.SubjectType,
+		SubjectID:   event.SubjectID,
+		IP:          event.IP,
+		Data:        data,
+		CreatedAt:   event.CreatedAt.UnixMilli(),
+	})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyResult reports how much of the chain was checked and, if it is broken, where.
+type VerifyResult struct {
+	Checked   int64  `json:"checked"`
+	Intact    bool   `json:"intact"`
+	BrokenSeq int64  `json:"broken_seq,omitempty"` // first event that does not check out
+	Reason    string `json:"reason,omitempty"`
+}
+
+// errChainBroken stops the walk at the first bad link.
+var errChainBroken = errors.New("audit chain broken")
+
+// Verify walks the whole chain in order and reports the first event whose sequence
+// number, link to its predecessor or own hash does not check out. Cutting events off the
+// end leaves a valid shorter chain, so compare Checked with earlier runs as well.
+func (l *Logger) Verify(ctx context.Context) (*VerifyResult, error) {
+	result := &VerifyResult{Intact: true}
+	var prev *Event
+
+	err := l.store.Walk(ctx, func(event *Event) error {
+		expectedSeq, expectedPrev := int64(1), ""
+		if prev != nil {
+			expectedSeq, expectedPrev = prev.Seq+1, prev.Hash
+		}
+
+		switch {
+		case event.Seq != expectedSeq:
+			result.Reason = fmt.Sprintf("expected sequence %d, found %d", expectedSeq, event.Seq)
+		case event.PrevHash != expectedPrev:
+			result.Reason = "previous hash does not match the preceding event"
+		case event.Hash != Hash(event):
+			result.Reason = "event contents do not match its hash"
+		}
+		if result.Reason != "" {
+			result.Intact = false
+			result.BrokenSeq = event.Seq
+			return errChainBroken
+		}
+
+		result.Checked++
+		prev = event
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestConcurrentAppendersKeepTheChain(t *testing.T) {
+	// two loggers stand in for two server instances sharing the collection
+	store := NewMemoryStore()
+	loggers := []*Logger{NewLogger(store), NewLogger(store)}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for _, logger := range loggers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				errs <- logger.Append(ctx, &Event{Type: EventTransfer})
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	result, err := loggers[0].Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !result.Intact || result.Checked != 40 {
+		t.Fatalf("got %+v, want 40 intact events", result)
+	}
+}
+
+// racingStore lets another instance take the next sequence number just before the first
+// append lands.
+type racingStore struct {
+	*MemoryStore
+	rival   *Logger
+	appends atomic.Int32
+}
+
+func (s *racingStore) Append(ctx context.Context, event *Event) error {
+	if s.appends.Add(1) == 1 {
+		if err := s.rival.Append(context.Background(), &Event{Type: EventLoginFailed}); err != nil {
+			return err
+		}
+	}
+	return s.MemoryStore.Append(ctx, event)
+}
+
+func newRacingLogger() (*Logger, *racingStore) {
+	shared := NewMemoryStore()
+	store := &racingStore{MemoryStore: shared, rival: NewLogger(shared)}
+	return NewLogger(store), store
+}
+
+func TestAppendRetriesALostRaceOutsideTransactions(t *testing.T) {
+	logger, store := newRacingLogger()
+
+	event := &Event{Type: EventTransfer}
+	if err := logger.Append(context.Background(), event); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if event.Seq != 2 || store.appends.Load() != 2 {
+		t.Fatalf("got seq %d after %d appends, want seq 2 on the second try", event.Seq, store.appends.Load())
+	}
+}
+
+func TestAppendLeavesALostRaceToTheTransaction(t *testing.T) {
+	logger, store := newRacingLogger()
+
+	// building a session needs no server
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+	session, err := client.StartSession()
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	defer session.EndSession(context.Background())
+	if err := session.StartTransaction(); err != nil {
+		t.Fatalf("StartTransaction: %v", err)
+	}
+	ctx := mongo.NewSessionContext(context.Background(), session)
+
+	err = logger.Append(ctx, &Event{Type: EventTransfer})
+	if !errors.Is(err, ErrSeqTaken) {
+		t.Fatalf("Append in a transaction = %v, want ErrSeqTaken", err)
+	}
+	var labeled mongo.LabeledError
+	if !errors.As(err, &labeled) || !labeled.HasErrorLabel("TransientTransactionError") {
+		t.Fatalf("error %v is not labelled for the driver to retry the transaction", err)
+	}
+	if got := store.appends.Load(); got != 1 {
+		t.Fatalf("store appended %d times on an aborted transaction, want 1", got)
+	}
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps the chain in a slice, for tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Last(ctx context.Context) (*Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) == 0 {
+		return nil, nil
+	}
+	last := s.events[len(s.events)-1]
+	return &last, nil
+}
+
+func (s *MemoryStore) Append(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.events {
+		if existing.Seq == event.Seq {
+			return ErrSeqTaken
+		}
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *MemoryStore) Walk(ctx context.Context, fn func(*Event) error) error {
+	s.mu.Lock()
+	events := append([]Event(nil), s.events...)
+	s.mu.Unlock()
+
+	for i := range events {
+		if err := fn(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
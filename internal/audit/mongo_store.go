@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps the chain in a collection. It has no update or delete paths; in
+// production the application's database user should also only be granted insert and find
+// on this collection. The unique seq index that Append relies on is built by the
+// migrations.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database, collectionName string) *MongoStore {
+	return &MongoStore{collection: db.Collection(collectionName)}
+}
+
+func (s *MongoStore) Last(ctx context.Context) (*Event, error) {
+	var event Event
+	err := s.collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (s *MongoStore) Append(ctx context.Context, event *Event) error {
+	_, err := s.collection.InsertOne(ctx, event)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrSeqTaken
+	}
+	return err
+}
+
+func (s *MongoStore) Walk(ctx context.Context, fn func(*Event) error) error {
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var event Event
+		if err := cursor.Decode(&event); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -8,14 +8,22 @@ type Config struct {
 	Auth     AuthConfig
 	KYC      KYCConfig
 	Rates    RatesConfig
+	Mail     MailConfig
+	Health   HealthConfig
 }
 
 type ServerConfig struct {
-	Port      string
-	Env       string
-	TimeOut   time.Duration
-	RateLimit int
-	Debug     bool
+	Port           string
+	Env            string
+	TimeOut        time.Duration
+	RateLimit      int    // requests per minute per client on ordinary routes
+	AuthRateLimit  int    // requests per minute per client on login, register and similar
+	RateLimitStore string // "memory" or "mongo"
+	Debug          bool
+	IdempotencyTTL time.Duration
+	PublicURL      string   // base for links sent to users, e.g. password reset
+	MetricsToken   string   // bearer token required on /metrics; may only be empty in debug mode, where it leaves it open
+	TrustedProxies []string // addresses or CIDRs whose X-Forwarded-For is believed
 }
 
 type DatabaseConfig struct {
@@ -28,22 +36,80 @@ type DatabaseConfig struct {
 }
 
 type AuthConfig struct {
-	JWTSecret        string
-	JWTAccessExpiry  time.Duration
-	JWTRefreshExpiry time.Duration
-	BcryptCost       int
+	JWTSecret          string
+	JWTAccessExpiry    time.Duration
+	JWTRefreshExpiry   time.Duration
+	BcryptCost         int
+	RevocationCacheTTL time.Duration
+	TwoFactorIssuer    string // shown next to the code in authenticator apps
+	ChallengeExpiry    time.Duration
+	ResetTokenExpiry   time.Duration
+	ResetMaxPerHour    int
+	EmailVerifyExpiry  time.Duration
+	RequireVerified    bool // unverified users may log in but not open accounts or move money
+
+	// brute-force protection on login
+	LoginFailureWindow time.Duration // failures older than this are forgotten
+	LoginBackoffAfter  int           // failures on an account before each attempt is delayed
+	LoginBackoffBase   time.Duration // first delay, doubled with every further failure
+	LoginBackoffMax    time.Duration
+	LockoutThreshold   int // failures that lock an account
+	LockoutDuration    time.Duration
+	IPLockoutThreshold int    // failures from one address, across all accounts, that block it
+	BootstrapAdmin     string // email of a verified user promoted to admin at startup while there is none
 }
 
 type KYCConfig struct {
+	Provider      string // "http" or "fake"; required, so a missing setting never fakes verification
+	AllowFake     bool   // lets "fake" run outside debug mode, for staging
 	VerifyURL     string
+	APIKey        string
 	APITimeout    time.Duration
 	MaxRetries    int
 	WebhookSecret string
+	FakeOutcome   string // decision the fake provider returns at once: "verified", "rejected" or empty for pending
+
+	// document storage
+	StorageBackend   string // "disk" or "gridfs"
+	StoragePath      string // root directory for the disk backend
+	EncryptionKey    string // base64 of 32 random bytes; uploads are disabled without it
+	MaxDocumentBytes int64
+
+	TiersFile string // tier limits, see kyc_tiers.example.json
 }
 
 type RatesConfig struct {
 	BaseCurrency   string
 	ExchangeAPIURL string
 	CacheDuration  time.Duration
+	MaxAge         time.Duration // past this, rates are refused rather than served stale
 	APIKey         string
+	Provider       string // "http" or "static"; required, so a missing setting never serves sample rates
+	AllowStatic    bool   // lets "static" run outside debug mode, for staging
+	StaticFile     string
+	APITimeout     time.Duration
+	FXSpreadBps    int // basis points taken off the mid rate on conversions
+	FXQuoteTTL     time.Duration
+	FXMaxRateAge   time.Duration // quotes are refused on rates older than this
+}
+
+type MailConfig struct {
+	Driver       string // "smtp", "file" or "log"
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FilePath     string
+}
+
+// HealthConfig bounds each readiness check and the pause between failing readiness
+// and closing the listener on shutdown.
+type HealthConfig struct {
+	MongoTimeout  time.Duration
+	RatesTimeout  time.Duration
+	RatesMaxAge   time.Duration // rates older than this fail the check
+	KYCTimeout    time.Duration
+	CacheFor      time.Duration // how long one readiness report answers every probe
+	ShutdownDrain time.Duration // how long load balancers get to notice before connections drain
 }
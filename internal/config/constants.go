@@ -3,16 +3,55 @@ package config
 import "time"
 
 const (
-	DefaultPort            = "8080"
-	DefaultEnv             = "development"
-	DefaultDBName          = "fintech"
-	DefaultMongoURI        = "mongodb://localhost:27017"
-	DefaultJWTExpiry       = 24 * time.Hour
-	DefaultBcryptCost      = 10
-	DefaultRateLimit       = 100
-	DefaultKYCVerifyURL    = "https://kyc-service.example.com"
-	DefaultConnectTimeout  = 5 * time.Second
-	DefaultSocketTimeout   = 30 * time.Second
-	DefaultMaxPoolSize     = 50
-	DefaultMinPoolSize     = 10
-)
\ No newline at end of file
+	DefaultPort           = "8080"
+	DefaultEnv            = "development"
+	DefaultDBName         = "fintech"
+	DefaultMongoURI       = "mongodb://localhost:27017"
+	DefaultJWTExpiry      = 24 * time.Hour
+	DefaultBcryptCost     = 10
+	DefaultRateLimit      = 100
+	DefaultAuthRateLimit  = 10
+	DefaultRateLimitStore = "memory"
+	DefaultKYCVerifyURL   = "https://kyc-service.example.com"
+	DefaultConnectTimeout = 5 * time.Second
+	DefaultSocketTimeout  = 30 * time.Second
+	DefaultMaxPoolSize    = 50
+	DefaultMinPoolSize    = 10
+	DefaultIdempotencyTTL = 24 * time.Hour
+	DefaultRatesFile      = "rates.example.json"
+	DefaultRatesMaxAge    = 24 * time.Hour
+	DefaultFXSpreadBps    = 50
+	DefaultFXQuoteTTL     = 30 * time.Second
+	DefaultFXMaxRateAge   = 2 * time.Hour
+
+	DefaultRevocationCacheTTL = 5 * time.Second
+	DefaultTwoFactorIssuer    = "Fintech Wallet"
+	DefaultChallengeExpiry    = 5 * time.Minute
+	DefaultResetTokenExpiry   = time.Hour
+	DefaultResetMaxPerHour    = 3
+	DefaultEmailVerifyExpiry  = 48 * time.Hour
+	DefaultLoginFailureWindow = 15 * time.Minute
+	DefaultLoginBackoffAfter  = 3
+	DefaultLoginBackoffBase   = time.Second
+	DefaultLoginBackoffMax    = 5 * time.Minute
+	DefaultLockoutThreshold   = 10
+	DefaultLockoutDuration    = 30 * time.Minute
+	DefaultIPLockoutThreshold = 100
+
+	DefaultPublicURL  = "http://localhost:8080"
+	DefaultMailDriver = "log"
+	DefaultMailFrom   = "Fintech Wallet <no-reply@example.com>"
+	DefaultMailFile   = "mail.log"
+
+	DefaultKYCStorageBackend = "disk"
+	DefaultKYCStoragePath    = "data/kyc"
+	DefaultKYCMaxDocumentMB  = 10
+	DefaultKYCTiersFile      = "kyc_tiers.example.json"
+
+	DefaultHealthMongoTimeout = 2 * time.Second
+	DefaultHealthRatesTimeout = 3 * time.Second
+	DefaultHealthRatesMaxAge  = 6 * time.Hour
+	DefaultHealthKYCTimeout   = 3 * time.Second
+	DefaultHealthCacheFor     = 5 * time.Second
+	DefaultShutdownDrain      = 5 * time.Second
+)
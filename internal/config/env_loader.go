@@ -1,7 +1,7 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
@@ -16,11 +16,17 @@ func LoadConfig() *Config {
 
 	return &Config{
 		Server: ServerConfig{
-			Port:      getEnv("PORT", DefaultPort),
-			Env:       getEnv("Env", DefaultEnv),
-			TimeOut:   parseDuration(getEnv("SERVER_TIMEOUT", "30s")),
-			RateLimit: getEnvAsInt("RATE_LIMIT", DefaultRateLimit),
-			Debug:     getEnvAsBool("DEBUG", false),
+			Port:           getEnv("PORT", DefaultPort),
+			Env:            getEnv("Env", DefaultEnv),
+			TimeOut:        parseDuration(getEnv("SERVER_TIMEOUT", "30s")),
+			RateLimit:      getEnvAsInt("RATE_LIMIT", DefaultRateLimit),
+			AuthRateLimit:  getEnvAsInt("AUTH_RATE_LIMIT", DefaultAuthRateLimit),
+			RateLimitStore: getEnv("RATE_LIMIT_STORE", DefaultRateLimitStore),
+			Debug:          getEnvAsBool("DEBUG", false),
+			IdempotencyTTL: parseDuration(getEnv("IDEMPOTENCY_TTL", DefaultIdempotencyTTL.String())),
+			PublicURL:      getEnv("APP_BASE_URL", DefaultPublicURL),
+			MetricsToken:   getEnv("METRICS_TOKEN", ""),
+			TrustedProxies: getEnvAsList("TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Uri:            getMongoURI(),
@@ -31,22 +37,71 @@ func LoadConfig() *Config {
 			SocketTimeout:  parseDuration(getEnv("DB_SOCKET_TIMEOUT", DefaultSocketTimeout.String())),
 		},
 		Auth: AuthConfig{
-			JWTSecret:        getEnv("JWT_SECRET", ""),
-			JWTAccessExpiry:  parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m")),
-			JWTRefreshExpiry: parseDuration(getEnv("JWT_REFRESH_EXPIRY", DefaultJWTExpiry.String())),
-			BcryptCost:       getEnvAsInt("BCRYPT_COST", DefaultBcryptCost),
+			JWTSecret:          getEnv("JWT_SECRET", ""),
+			JWTAccessExpiry:    parseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m")),
+			JWTRefreshExpiry:   parseDuration(getEnv("JWT_REFRESH_EXPIRY", DefaultJWTExpiry.String())),
+			BcryptCost:         getEnvAsInt("BCRYPT_COST", DefaultBcryptCost),
+			RevocationCacheTTL: parseDuration(getEnv("REVOCATION_CACHE_TTL", DefaultRevocationCacheTTL.String())),
+			TwoFactorIssuer:    getEnv("TWO_FACTOR_ISSUER", DefaultTwoFactorIssuer),
+			ChallengeExpiry:    parseDuration(getEnv("TWO_FACTOR_CHALLENGE_EXPIRY", DefaultChallengeExpiry.String())),
+			ResetTokenExpiry:   parseDuration(getEnv("PASSWORD_RESET_EXPIRY", DefaultResetTokenExpiry.String())),
+			ResetMaxPerHour:    getEnvAsInt("PASSWORD_RESET_MAX_PER_HOUR", DefaultResetMaxPerHour),
+			EmailVerifyExpiry:  parseDuration(getEnv("EMAIL_VERIFY_EXPIRY", DefaultEmailVerifyExpiry.String())),
+			RequireVerified:    getEnvAsBool("REQUIRE_VERIFIED_EMAIL", true),
+			LoginFailureWindow: parseDuration(getEnv("LOGIN_FAILURE_WINDOW", DefaultLoginFailureWindow.String())),
+			LoginBackoffAfter:  getEnvAsInt("LOGIN_BACKOFF_AFTER", DefaultLoginBackoffAfter),
+			LoginBackoffBase:   parseDuration(getEnv("LOGIN_BACKOFF_BASE", DefaultLoginBackoffBase.String())),
+			LoginBackoffMax:    parseDuration(getEnv("LOGIN_BACKOFF_MAX", DefaultLoginBackoffMax.String())),
+			LockoutThreshold:   getEnvAsInt("LOGIN_LOCKOUT_THRESHOLD", DefaultLockoutThreshold),
+			LockoutDuration:    parseDuration(getEnv("LOGIN_LOCKOUT_DURATION", DefaultLockoutDuration.String())),
+			IPLockoutThreshold: getEnvAsInt("LOGIN_IP_LOCKOUT_THRESHOLD", DefaultIPLockoutThreshold),
+			BootstrapAdmin:     getEnv("BOOTSTRAP_ADMIN_EMAIL", ""),
 		},
 		KYC: KYCConfig{
-			VerifyURL:     getEnv("KYC_VERIFY_URL", DefaultKYCVerifyURL),
-			APITimeout:    parseDuration(getEnv("KYC_TIMEOUT", "10s")),
-			MaxRetries:    getEnvAsInt("KYC_MAX_RETRIES", 3),
-			WebhookSecret: getEnv("KYC_WEBHOOK_SECRET", ""),
+			Provider:         getEnv("KYC_PROVIDER", ""),
+			AllowFake:        getEnvAsBool("KYC_ALLOW_FAKE", false),
+			VerifyURL:        getEnv("KYC_VERIFY_URL", DefaultKYCVerifyURL),
+			APIKey:           getEnv("KYC_API_KEY", ""),
+			APITimeout:       parseDuration(getEnv("KYC_TIMEOUT", "10s")),
+			MaxRetries:       getEnvAsInt("KYC_MAX_RETRIES", 3),
+			WebhookSecret:    getEnv("KYC_WEBHOOK_SECRET", ""),
+			FakeOutcome:      getEnv("KYC_FAKE_OUTCOME", "pending"),
+			StorageBackend:   getEnv("KYC_STORAGE_BACKEND", DefaultKYCStorageBackend),
+			StoragePath:      getEnv("KYC_STORAGE_PATH", DefaultKYCStoragePath),
+			EncryptionKey:    getEnv("KYC_ENCRYPTION_KEY", ""),
+			MaxDocumentBytes: int64(getEnvAsInt("KYC_MAX_DOCUMENT_MB", DefaultKYCMaxDocumentMB)) << 20,
+			TiersFile:        getEnv("KYC_TIERS_FILE", DefaultKYCTiersFile),
 		},
 		Rates: RatesConfig{
 			BaseCurrency:   getEnv("BASE_CURRENCY", "USD"),
 			ExchangeAPIURL: getEnv("EXCHANGE_API_URL", ""),
 			CacheDuration:  parseDuration(getEnv("RATES_CACHE_DURATION", "1h")),
-			APIKey:        getEnv("EXCHANGE_API_KEY", ""),
+			MaxAge:         parseDuration(getEnv("RATES_MAX_AGE", DefaultRatesMaxAge.String())),
+			APIKey:         getEnv("EXCHANGE_API_KEY", ""),
+			Provider:       getEnv("RATES_PROVIDER", ""),
+			AllowStatic:    getEnvAsBool("RATES_ALLOW_STATIC", false),
+			StaticFile:     getEnv("RATES_FILE", DefaultRatesFile),
+			APITimeout:     parseDuration(getEnv("EXCHANGE_API_TIMEOUT", "5s")),
+			FXSpreadBps:    getEnvAsIntInRange("FX_SPREAD_BPS", DefaultFXSpreadBps, 0, 10000),
+			FXQuoteTTL:     parseDuration(getEnv("FX_QUOTE_TTL", DefaultFXQuoteTTL.String())),
+			FXMaxRateAge:   parseDuration(getEnv("FX_MAX_RATE_AGE", DefaultFXMaxRateAge.String())),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", DefaultMailDriver),
+			From:         getEnv("MAIL_FROM", DefaultMailFrom),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			FilePath:     getEnv("MAIL_FILE", DefaultMailFile),
+		},
+		Health: HealthConfig{
+			MongoTimeout:  parseDuration(getEnv("HEALTH_MONGO_TIMEOUT", DefaultHealthMongoTimeout.String())),
+			RatesTimeout:  parseDuration(getEnv("HEALTH_RATES_TIMEOUT", DefaultHealthRatesTimeout.String())),
+			RatesMaxAge:   parseDuration(getEnv("HEALTH_RATES_MAX_AGE", DefaultHealthRatesMaxAge.String())),
+			KYCTimeout:    parseDuration(getEnv("HEALTH_KYC_TIMEOUT", DefaultHealthKYCTimeout.String())),
+			CacheFor:      parseDuration(getEnv("HEALTH_CACHE_TTL", DefaultHealthCacheFor.String())),
+			ShutdownDrain: parseDuration(getEnv("SHUTDOWN_DRAIN", DefaultShutdownDrain.String())),
 		},
 	}
 }
@@ -77,11 +132,22 @@ func getEnv(key string, Defaultval string) string {
 		return value
 	}
 	if Defaultval == "" {
-		log.Printf("WARNING: Required environment variable %s not set", key)
+		slog.Warn("Required environment variable not set", "key", key)
 	}
 	return Defaultval
 }
 
+// getEnvAsList splits a comma-separated value; unset or empty gives nil
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	strValue := getEnv(key, "")
 	if strValue == "" {
@@ -89,7 +155,7 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	value, err := strconv.ParseBool(strValue)
 	if err != nil {
-		log.Printf("Invalid boolean value for %s, using default: %v", key, defaultValue)
+		slog.Warn("Invalid boolean value, using default", "key", key, "default", defaultValue)
 		return defaultValue
 	}
 	return value
@@ -101,7 +167,17 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	value, err := strconv.Atoi(strValue)
 	if err != nil {
-		log.Printf("Invalid value for %s, using default: %v", key, defaultValue)
+		slog.Warn("Invalid value, using default", "key", key, "default", defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsIntInRange falls back to the default for values outside [min, max]
+func getEnvAsIntInRange(key string, defaultValue, min, max int) int {
+	value := getEnvAsInt(key, defaultValue)
+	if value < min || value > max {
+		slog.Warn("Value out of range, using default", "key", key, "min", min, "max", max, "default", defaultValue)
 		return defaultValue
 	}
 	return value
@@ -114,7 +190,7 @@ func getEnvAsUint64(key string, defaultValue uint64) uint64 {
 	}
 	value, err := strconv.ParseUint(strValue, 10, 64)
 	if err != nil {
-		log.Printf("Invalid value for %s, using default: %v", key, defaultValue)
+		slog.Warn("Invalid value, using default", "key", key, "default", defaultValue)
 		return defaultValue
 	}
 	return value
@@ -123,7 +199,7 @@ func getEnvAsUint64(key string, defaultValue uint64) uint64 {
 func parseDuration(durationStr string) time.Duration {
 	duration, err := time.ParseDuration(durationStr)
 	if err != nil {
-		log.Printf("Invalid duration format for %s, defaulting to 0", durationStr)
+		slog.Warn("Invalid duration format, defaulting to 0", "value", durationStr)
 		return 0
 	}
 	return duration
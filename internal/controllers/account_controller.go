@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+type AccountController struct {
+	accountService *services.AccountService
+}
+
+func NewAccountController(accountService *services.AccountService) *AccountController {
+	return &AccountController{accountService: accountService}
+}
+
+func (c *AccountController) CreateAccount(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		Currency string `json:"currency" binding:"required,len=3"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := c.accountService.CreateAccount(ctx.Request.Context(), userID.(string), req.Currency)
+	if err != nil {
+		var limitErr *services.LimitError
+		switch {
+		case errors.As(err, &limitErr):
+			ctx.JSON(http.StatusForbidden, limitErrorResponse(limitErr))
+		case errors.Is(err, services.ErrUnsupportedCurrency):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrAccountExists):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, accountResponse(account))
+}
+
+func (c *AccountController) ListAccounts(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	accounts, err := c.accountService.ListAccounts(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list accounts"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(accounts))
+	for i := range accounts {
+		response = append(response, accountResponse(&accounts[i]))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"accounts": response})
+}
+
+func (c *AccountController) GetAccount(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	account, err := c.accountService.GetAccount(ctx.Request.Context(), userID.(string), ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load account"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountResponse(account))
+}
+
+// GetBalance proves the account balance from the ledger, optionally as of ?at=<RFC3339>
+func (c *AccountController) GetBalance(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	at := c.accountService.Clock.Now()
+	if raw := ctx.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC3339 timestamp"})
+			return
+		}
+		at = parsed
+	}
+
+	balance, reconciliation, err := c.accountService.BalanceAt(ctx.Request.Context(), userID.(string), ctx.Param("id"), at)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute balance"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"accountId":  reconciliation.AccountID.Hex(),
+		"currency":   balance.Currency,
+		"balance":    balance,
+		"at":         at,
+		"reconciled": reconciliation.Balanced(),
+	})
+}
+
+func accountResponse(account *models.Account) gin.H {
+	return gin.H{
+		"id":        account.ID.Hex(),
+		"currency":  account.Currency,
+		"balance":   account.Balance,
+		"isActive":  account.IsActive,
+		"createdAt": account.CreatedAt,
+	}
+}
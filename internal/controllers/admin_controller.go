@@ -0,0 +1,401 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/middlewares"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type AdminController struct {
+	adminService    *services.AdminService
+	kycService      *services.KYCService
+	documentService *services.KYCDocumentService
+	loginProtection *services.LoginProtectionService
+}
+
+func NewAdminController(
+	adminService *services.AdminService,
+	kycService *services.KYCService,
+	documentService *services.KYCDocumentService,
+	loginProtection *services.LoginProtectionService,
+) *AdminController {
+	return &AdminController{
+		adminService:    adminService,
+		kycService:      kycService,
+		documentService: documentService,
+		loginProtection: loginProtection,
+	}
+}
+
+// ListUsers searches users by email or name (q), KYC status and role
+func (c *AdminController) ListUsers(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	filter := repositories.UserFilter{
+		Query:     ctx.Query("q"),
+		KYCStatus: ctx.Query("kyc_status"),
+		Role:      ctx.Query("role"),
+	}
+	describeAction(ctx, "users.search", "", "", gin.H{"q": filter.Query, "kyc_status": filter.KYCStatus, "role": filter.Role})
+
+	users, err := c.adminService.SearchUsers(filter, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(users))
+	for i := range users {
+		response = append(response, staffUserResponse(&users[i]))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"users": response, "page": page, "limit": limit})
+}
+
+func (c *AdminController) GetUser(ctx *gin.Context) {
+	userID := ctx.Param("id")
+	describeAction(ctx, "users.view", "user", userID, nil)
+
+	user, accounts, err := c.adminService.GetUser(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	response := staffUserResponse(user)
+	accountList := make([]gin.H, 0, len(accounts))
+	for i := range accounts {
+		accountList = append(accountList, staffAccountResponse(&accounts[i]))
+	}
+	response["accounts"] = accountList
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UnlockUser lifts a brute-force lockout before it would expire on its own
+func (c *AdminController) UnlockUser(ctx *gin.Context) {
+	userID := ctx.Param("id")
+	describeAction(ctx, "users.unlock", "user", userID, nil)
+
+	if _, _, err := c.adminService.GetUser(ctx.Request.Context(), userID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := c.loginProtection.Unlock(ctx.Request.Context(), userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+func (c *AdminController) SetRole(ctx *gin.Context) {
+	userID := ctx.Param("id")
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	action := describeChange(ctx, "users.set_role", "user", userID, gin.H{"role": req.Role})
+
+	user, err := c.adminService.SetRole(ctx.Request.Context(), action, userID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOwnRoleChange):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, staffUserResponse(user))
+}
+
+// ListKYCCases returns the review queue, oldest first; status defaults to submitted
+func (c *AdminController) ListKYCCases(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	status := ctx.DefaultQuery("status", models.KYCCaseStatusSubmitted)
+	describeAction(ctx, "kyc.list", "", "", gin.H{"status": status})
+
+	cases, err := c.kycService.CaseRepo.List(ctx.Request.Context(), status, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list KYC cases"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(cases))
+	for i := range cases {
+		response = append(response, staffKYCCaseResponse(&cases[i]))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cases": response, "page": page, "limit": limit})
+}
+
+func (c *AdminController) GetKYCCase(ctx *gin.Context) {
+	caseID := ctx.Param("id")
+	describeAction(ctx, "kyc.view", "kyc_case", caseID, nil)
+
+	kycCase, err := c.kycService.CaseRepo.FindByID(ctx.Request.Context(), caseID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrKYCCaseNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load KYC case"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, staffKYCCaseResponse(kycCase))
+}
+
+// DownloadKYCDocument streams the decrypted document so a reviewer can look at it
+func (c *AdminController) DownloadKYCDocument(ctx *gin.Context) {
+	caseID, docID := ctx.Param("id"), ctx.Param("docId")
+	describeAction(ctx, "kyc.document.download", "kyc_case", caseID, gin.H{"document_id": docID})
+
+	doc, body, err := c.documentService.OpenDocument(ctx.Request.Context(), caseID, docID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrKYCCaseNotFound), errors.Is(err, services.ErrKYCDocumentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDocumentStorageDisabled):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read document"})
+		}
+		return
+	}
+	defer body.Close()
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Content-Disposition", "attachment; filename=\""+doc.Type+"-"+doc.ID.Hex()+"\"")
+	ctx.Header("Content-Type", doc.ContentType)
+	ctx.Status(http.StatusOK)
+	if _, err := io.Copy(ctx.Writer, body); err != nil {
+		ctx.Error(err)
+	}
+}
+
+// DecideKYCCase records a reviewer's decision on a submitted case
+func (c *AdminController) DecideKYCCase(ctx *gin.Context) {
+	caseID := ctx.Param("id")
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason" binding:"max=500"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	action := describeChange(ctx, "kyc.decide", "kyc_case", caseID, gin.H{"status": req.Status, "reason": req.Reason})
+
+	kycCase, err := c.adminService.DecideKYCCase(ctx.Request.Context(), action, caseID, req.Status, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidKYCDecision):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrKYCCaseNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKYCCaseNotSubmitted):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record decision"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, staffKYCCaseResponse(kycCase))
+}
+
+func (c *AdminController) FreezeAccount(ctx *gin.Context) {
+	accountID := ctx.Param("id")
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	action := describeChange(ctx, "accounts.freeze", "account", accountID, gin.H{"reason": req.Reason})
+
+	account, err := c.adminService.FreezeAccount(ctx.Request.Context(), action, accountID, req.Reason)
+	c.respondAccount(ctx, account, err)
+}
+
+func (c *AdminController) UnfreezeAccount(ctx *gin.Context) {
+	accountID := ctx.Param("id")
+	action := describeChange(ctx, "accounts.unfreeze", "account", accountID, nil)
+
+	account, err := c.adminService.UnfreezeAccount(ctx.Request.Context(), action, accountID)
+	c.respondAccount(ctx, account, err)
+}
+
+func (c *AdminController) respondAccount(ctx *gin.Context, account *models.Account, err error) {
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+	ctx.JSON(http.StatusOK, staffAccountResponse(account))
+}
+
+// ListTransactions filters by account_id or user_id, or lists the latest across the wallet
+func (c *AdminController) ListTransactions(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	userID, accountID := ctx.Query("user_id"), ctx.Query("account_id")
+	describeAction(ctx, "transactions.list", "", "", gin.H{"user_id": userID, "account_id": accountID})
+
+	transactions, err := c.adminService.ListTransactions(ctx.Request.Context(), userID, accountID, page, limit)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid account_id"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list transactions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"transactions": transactions, "page": page, "limit": limit})
+}
+
+func (c *AdminController) GetTransaction(ctx *gin.Context) {
+	transactionID := ctx.Param("id")
+	describeAction(ctx, "transactions.view", "transaction", transactionID, nil)
+
+	tx, err := c.adminService.GetTransaction(ctx.Request.Context(), transactionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTransactionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load transaction"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tx)
+}
+
+// ListActions shows the staff action log, optionally for one staff member
+func (c *AdminController) ListActions(ctx *gin.Context) {
+	page, limit := pagination(ctx)
+	staffID := ctx.Query("staff_id")
+	describeAction(ctx, "actions.list", "", "", gin.H{"staff_id": staffID})
+
+	actions, err := c.adminService.ListActions(ctx.Request.Context(), staffID, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"actions": actions, "page": page, "limit": limit})
+}
+
+// VerifyAuditLog walks the audit chain and reports the first tampered event, if any
+func (c *AdminController) VerifyAuditLog(ctx *gin.Context) {
+	describeAction(ctx, "audit.verify", "", "", nil)
+
+	result, err := c.adminService.VerifyAuditLog(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit log"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// describeAction names what an admin handler is doing for the audit middleware
+func describeAction(ctx *gin.Context, name, targetType, targetID string, details gin.H) *models.AdminAction {
+	action := ctx.MustGet(middlewares.AdminActionKey).(*models.AdminAction)
+	action.Action = name
+	action.TargetType = targetType
+	action.TargetID = targetID
+	action.Details = details
+	return action
+}
+
+// describeChange describes an action the service stores in the transaction making the
+// change. That only commits when the handler goes on to answer 200.
+func describeChange(ctx *gin.Context, name, targetType, targetID string, details gin.H) *models.AdminAction {
+	action := describeAction(ctx, name, targetType, targetID, details)
+	action.Status = http.StatusOK
+	return action
+}
+
+// pagination reads page and limit from the query string, falling back to sane values
+func pagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	return page, limit
+}
+
+func staffUserResponse(user *models.User) gin.H {
+	role := user.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	return gin.H{
+		"id":                 user.ID.Hex(),
+		"email":              user.Email,
+		"full_name":          user.FullName,
+		"role":               role,
+		"email_verified":     user.EmailVerified,
+		"kycStatus":          user.KYCStatus,
+		"kyc_tier":           user.KYCTier,
+		"kyc_case_id":        user.KYCCaseID,
+		"kyc_rejection":      user.KYCRejectionReason,
+		"two_factor_enabled": user.TwoFactorEnabled,
+		"createdAt":          user.CreatedAt,
+	}
+}
+
+func staffAccountResponse(account *models.Account) gin.H {
+	response := accountResponse(account)
+	response["frozen_at"] = account.FrozenAt
+	response["freeze_reason"] = account.FreezeReason
+	return response
+}
+
+func staffKYCCaseResponse(kycCase *models.KYCCase) gin.H {
+	return gin.H{
+		"id":           kycCase.ID.Hex(),
+		"user_id":      kycCase.UserID.Hex(),
+		"status":       kycCase.Status,
+		"reference":    kycCase.KYCReference,
+		"documents":    kycCase.Documents,
+		"submitted_at": kycCase.SubmittedAt,
+		"decided_at":   kycCase.DecidedAt,
+		"createdAt":    kycCase.CreatedAt,
+	}
+}
@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/samoray1998/fintech-wallet/internal/models"
@@ -9,14 +13,20 @@ import (
 )
 
 type AuthController struct {
-	authService *services.AuthService
-	userService *services.UserServices
+	authService      *services.AuthService
+	userService      *services.UserServices
+	twoFactorService *services.TwoFactorService
+	emailService     *services.EmailVerificationService
+	loginProtection  *services.LoginProtectionService
 }
 
-func NewAuthController(authService *services.AuthService, userService *services.UserServices) *AuthController {
+func NewAuthController(authService *services.AuthService, userService *services.UserServices, twoFactorService *services.TwoFactorService, emailService *services.EmailVerificationService, loginProtection *services.LoginProtectionService) *AuthController {
 	return &AuthController{
-		authService: authService,
-		userService: userService,
+		authService:      authService,
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		emailService:     emailService,
+		loginProtection:  loginProtection,
 	}
 }
 
@@ -42,7 +52,59 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, createdUser)
+	// the account exists either way; a lost email can be resent after logging in
+	if err := c.emailService.SendVerification(ctx.Request.Context(), createdUser); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Failed to send verification email", "user_id", createdUser.ID.Hex(), "error", err)
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":             createdUser.ID.Hex(),
+		"full_name":      createdUser.FullName,
+		"email":          createdUser.Email,
+		"email_verified": createdUser.EmailVerified,
+		"kycStatus":      createdUser.KYCStatus,
+		"createdAt":      createdUser.CreatedAt,
+	})
+}
+
+func (c *AuthController) VerifyEmail(ctx *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.emailService.Verify(ctx.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerification) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (c *AuthController) ResendVerification(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := c.emailService.Resend(ctx.Request.Context(), userID.(string)); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
 }
 
 func (c *AuthController) Login(ctx *gin.Context) {
@@ -56,24 +118,232 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.userService.VerifyCredentials(creds.Email, creds.Password)
+	user, err := c.loginProtection.Login(ctx.Request.Context(), creds.Email, creds.Password, ctx.ClientIP())
+	if err != nil {
+		var throttled *services.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			respondThrottled(ctx, throttled, "login_throttled", "account_locked")
+		case errors.Is(err, services.ErrInvalidCredentials):
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials "})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		}
+		return
+	}
+
+	// with 2FA on, the password only earns a challenge that /auth/2fa/verify exchanges for tokens
+	if user.TwoFactorEnabled {
+		challenge, err := c.authService.IssueChallenge(user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int64(c.authService.ChallengeExpiry.Seconds()),
+		})
+		return
+	}
+
+	c.respondWithTokens(ctx, user)
+}
+
+// VerifyTwoFactor completes a 2FA login with the challenge token and a TOTP or recovery code
+func (c *AuthController) VerifyTwoFactor(ctx *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := c.authService.ConsumeChallenge(ctx.Request.Context(), req.ChallengeToken)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials "})
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrInvalidChallenge.Error()})
+		return
+	}
+
+	if err := c.twoFactorService.VerifyCode(ctx.Request.Context(), user, req.Code); err != nil {
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondThrottled(ctx, throttled, "2fa_throttled", "2fa_locked")
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrInvalidTwoFactorCode.Error()})
 		return
 	}
 
-	token, err := c.authService.GenerateTokens(user)
+	c.respondWithTokens(ctx, user)
+}
+
+// respondThrottled answers 429, or 423 once locked, with the wait in Retry-After.
+func respondThrottled(ctx *gin.Context, throttled *services.LoginThrottledError, throttledCode, lockedCode string) {
+	ctx.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(throttled.RetryAfter.Seconds())), 10))
+	status, code := http.StatusTooManyRequests, throttledCode
+	if throttled.Locked {
+		status, code = http.StatusLocked, lockedCode
+	}
+	ctx.JSON(status, gin.H{"error": throttled.Error(), "code": code})
+}
+
+func (c *AuthController) respondWithTokens(ctx *gin.Context, user *models.User) {
+	tokens, err := c.authService.GenerateTokens(ctx.Request.Context(), user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
 		"user": gin.H{
-			"id":        user.ID.Hex(),
-			"email":     user.Email,
-			"kycStatus": user.KYCStatus,
+			"id":             user.ID.Hex(),
+			"email":          user.Email,
+			"email_verified": user.EmailVerified,
+			"kycStatus":      user.KYCStatus,
 		},
 	})
 }
+
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := c.authService.Refresh(ctx.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int64(tokens.ExpiresIn.Seconds()),
+	})
+}
+
+func (c *AuthController) Logout(ctx *gin.Context) {
+	claims, exists := ctx.Get("claims")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := c.authService.Logout(ctx.Request.Context(), claims.(*services.AccessClaims)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func (c *AuthController) LogoutAll(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := c.authService.LogoutAll(ctx.Request.Context(), userID.(string)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions"})
+}
+
+func (c *AuthController) EnrollTwoFactor(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	secret, uri, err := c.twoFactorService.Enroll(userID.(string))
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor enrolment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"secret": secret, "otpauth_uri": uri})
+}
+
+func (c *AuthController) ActivateTwoFactor(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := c.twoFactorService.Activate(userID.(string), req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTwoFactorNotEnrolled), errors.Is(err, services.ErrInvalidTwoFactorCode):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (c *AuthController) DisableTwoFactor(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.twoFactorService.Disable(ctx.Request.Context(), userID.(string), req.Code); err != nil {
+		var throttled *services.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			respondThrottled(ctx, throttled, "2fa_throttled", "2fa_locked")
+		case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrInvalidTwoFactorCode):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+type FXController struct {
+	fxService *services.FXService
+}
+
+func NewFXController(fxService *services.FXService) *FXController {
+	return &FXController{fxService: fxService}
+}
+
+func (c *FXController) CreateQuote(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		FromAccountID string      `json:"from_account_id" binding:"required"`
+		ToAccountID   string      `json:"to_account_id" binding:"required"`
+		Amount        money.Money `json:"amount"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := c.fxService.CreateQuote(ctx.Request.Context(), userID.(string), req.FromAccountID, req.ToAccountID, req.Amount)
+	if err != nil {
+		respondFXError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":          quote.ID.Hex(),
+		"fromAccount": quote.FromAccount.Hex(),
+		"toAccount":   quote.ToAccount.Hex(),
+		"sell":        quote.Sell,
+		"buy":         quote.Buy,
+		"rate":        quote.Rate,
+		"midRate":     quote.MidRate,
+		"spreadBps":   quote.SpreadBps,
+		"expiresAt":   quote.ExpiresAt,
+	})
+}
+
+func (c *FXController) ExecuteConversion(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		QuoteID string `json:"quote_id" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	legs, err := c.fxService.ExecuteConversion(ctx.Request.Context(), userID.(string), req.QuoteID)
+	if err != nil {
+		respondFXError(ctx, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(legs))
+	for i := range legs {
+		leg := transactionResponse(&legs[i])
+		leg["linkedId"] = legs[i].LinkedID.Hex()
+		response = append(response, leg)
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"quoteId": req.QuoteID, "transactions": response})
+}
+
+func respondFXError(ctx *gin.Context, err error) {
+	var transferErr *services.TransferError
+	var limitErr *services.LimitError
+	switch {
+	case errors.As(err, &limitErr):
+		ctx.JSON(http.StatusForbidden, limitErrorResponse(limitErr))
+	case errors.Is(err, services.ErrQuoteNotFound), errors.Is(err, repositories.ErrAccountNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.As(err, &transferErr):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": transferErr.Message, "code": transferErr.Code})
+	case errors.Is(err, services.ErrRatesUnavailable), errors.Is(err, services.ErrRateNotFound):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "No exchange rate available for this currency pair"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Conversion failed"})
+	}
+}
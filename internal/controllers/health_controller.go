@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+type HealthController struct {
+	healthService *services.HealthService
+	detailToken   string // bearer token that unlocks check errors and timings; empty shows them to no one
+}
+
+func NewHealthController(healthService *services.HealthService, detailToken string) *HealthController {
+	return &HealthController{healthService: healthService, detailToken: detailToken}
+}
+
+// Liveness only says the process is serving requests; dependencies are readiness' concern,
+// so a database outage does not get every instance restarted.
+func (c *HealthController) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": services.HealthOK})
+}
+
+// Readiness answers 503 while a critical dependency is failing or the server is draining.
+// Anonymous callers only get each check's name and status; errors can name hosts and
+// providers, so they are logged and only shown to callers with the detail token.
+func (c *HealthController) Readiness(ctx *gin.Context) {
+	report := c.healthService.Readiness(ctx.Request.Context())
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	if c.showDetail(ctx) {
+		ctx.JSON(status, report)
+		return
+	}
+
+	checks := make([]gin.H, 0, len(report.Checks))
+	for _, check := range report.Checks {
+		checks = append(checks, gin.H{"name": check.Name, "status": check.Status})
+	}
+	ctx.JSON(status, gin.H{"status": report.Status, "ready": report.Ready, "checks": checks})
+}
+
+func (c *HealthController) showDetail(ctx *gin.Context) bool {
+	if c.detailToken == "" {
+		return false
+	}
+	given := ctx.GetHeader("Authorization")
+	return subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+c.detailToken)) == 1
+}
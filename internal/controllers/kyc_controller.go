@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+const (
+	KYCSignatureHeader        = "X-KYC-Signature"
+	maxKYCWebhookBodyBytes    = 64 << 10
+	maxMultipartOverheadBytes = 64 << 10
+)
+
+type KYCController struct {
+	kycService      *services.KYCService
+	documentService *services.KYCDocumentService
+	maxUploadBytes  int64
+}
+
+func NewKYCController(kycService *services.KYCService, documentService *services.KYCDocumentService, maxUploadBytes int64) *KYCController {
+	return &KYCController{
+		kycService:      kycService,
+		documentService: documentService,
+		maxUploadBytes:  maxUploadBytes,
+	}
+}
+
+// UploadDocument accepts one multipart file in "file" with its document type in "type"
+func (c *KYCController) UploadDocument(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// leave room for the multipart framing around the file itself
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxUploadBytes+maxMultipartOverheadBytes)
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrDocumentTooLarge.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fileHeader.Size > c.maxUploadBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrDocumentTooLarge.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file could not be read"})
+		return
+	}
+	defer file.Close()
+
+	doc, err := c.documentService.Upload(ctx.Request.Context(), userID.(string), ctx.PostForm("type"), file)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidDocumentType), errors.Is(err, services.ErrUnsupportedDocumentMIME):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDocumentTooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKYCAlreadyPending), errors.Is(err, services.ErrKYCAlreadyVerified):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDocumentStorageDisabled):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store document"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, doc)
+}
+
+func (c *KYCController) GetCase(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	kycCase, err := c.documentService.CurrentCase(ctx.Request.Context(), userID.(string))
+	if err != nil {
+		if errors.Is(err, repositories.ErrKYCCaseNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load KYC case"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"id":           kycCase.ID.Hex(),
+		"status":       kycCase.Status,
+		"documents":    kycCase.Documents,
+		"submitted_at": kycCase.SubmittedAt,
+		"decided_at":   kycCase.DecidedAt,
+		"createdAt":    kycCase.CreatedAt,
+	})
+}
+
+func (c *KYCController) InitiateKYC(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		DateOfBirth string `json:"date_of_birth" binding:"required,datetime=2006-01-02"`
+		Country     string `json:"country" binding:"required,iso3166_1_alpha2"`
+		Address     string `json:"address"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := c.kycService.Initiate(ctx.Request.Context(), userID.(string), services.KYCApplicant{
+		DateOfBirth: req.DateOfBirth,
+		Country:     req.Country,
+		Address:     req.Address,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrKYCAlreadyPending), errors.Is(err, services.ErrKYCAlreadyVerified):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKYCDocumentsMissing):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKYCProviderUnavailable):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start identity verification"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"kycStatus":    user.KYCStatus,
+		"reference":    user.KYCReference,
+		"submitted_at": user.KYCSubmittedAt,
+	})
+}
+
+// Webhook receives verification decisions from the KYC provider
+func (c *KYCController) Webhook(ctx *gin.Context) {
+	payload, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxKYCWebhookBodyBytes))
+	if err != nil {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+		return
+	}
+
+	if err := c.kycService.HandleWebhook(ctx.Request.Context(), payload, ctx.GetHeader(KYCSignatureHeader)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidKYCSignature):
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidKYCEvent):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKYCReferenceNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrKYCWebhookDisabled):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "Webhook not configured"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"received": true})
+}
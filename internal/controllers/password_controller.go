@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+type PasswordController struct {
+	resetService *services.PasswordResetService
+}
+
+func NewPasswordController(resetService *services.PasswordResetService) *PasswordController {
+	return &PasswordController{resetService: resetService}
+}
+
+func (c *PasswordController) ForgotPassword(ctx *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.resetService.RequestReset(ctx.Request.Context(), req.Email)
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If that email is registered, a reset link is on its way"})
+}
+
+func (c *PasswordController) ResetPassword(ctx *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.resetService.ResetPassword(ctx.Request.Context(), req.Token, req.Password); err != nil {
+		if errors.Is(err, repositories.ErrResetTokenInvalid) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password updated, please log in again"})
+}
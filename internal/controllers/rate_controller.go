@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+type RateController struct {
+	rateService *services.RateService
+}
+
+func NewRateController(rateService *services.RateService) *RateController {
+	return &RateController{rateService: rateService}
+}
+
+// GetCurrentRates returns rates relative to the base currency, optionally filtered by ?symbols=EUR,GBP
+func (c *RateController) GetCurrentRates(ctx *gin.Context) {
+	snapshot, err := c.rateService.GetRates(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "Exchange rates are currently unavailable"})
+		return
+	}
+
+	rates := snapshot.Rates
+	if symbols := ctx.Query("symbols"); symbols != "" {
+		rates = services.Rates{}
+		for _, symbol := range strings.Split(symbols, ",") {
+			symbol = money.NormalizeCurrency(symbol)
+			if rate, ok := snapshot.Rates[symbol]; ok {
+				rates[symbol] = rate
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"base":       snapshot.Base,
+		"rates":      rates,
+		"timestamp":  snapshot.FetchedAt,
+		"ageSeconds": int64(c.rateService.Age(snapshot).Seconds()),
+	})
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+type TransactionController struct {
+	transactionService *services.TransactionService
+}
+
+func NewTransactionController(transactionService *services.TransactionService) *TransactionController {
+	return &TransactionController{transactionService: transactionService}
+}
+
+func (c *TransactionController) CreateTransaction(ctx *gin.Context) {
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		FromAccountID  string      `json:"from_account_id" binding:"required"`
+		ToAccountID    string      `json:"to_account_id"`
+		RecipientEmail string      `json:"recipient_email" binding:"omitempty,email"`
+		Amount         money.Money `json:"amount"`
+		Description    string      `json:"description" binding:"max=140"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.ToAccountID == "") == (req.RecipientEmail == "") {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of to_account_id or recipient_email is required"})
+		return
+	}
+
+	tx, err := c.transactionService.Transfer(ctx.Request.Context(), userID.(string), services.TransferRequest{
+		FromAccountID:  req.FromAccountID,
+		ToAccountID:    req.ToAccountID,
+		RecipientEmail: req.RecipientEmail,
+		Amount:         req.Amount,
+		Description:    req.Description,
+	})
+	if err != nil {
+		var transferErr *services.TransferError
+		var limitErr *services.LimitError
+		switch {
+		case errors.As(err, &limitErr):
+			ctx.JSON(http.StatusForbidden, limitErrorResponse(limitErr))
+		case errors.As(err, &transferErr):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": transferErr.Message, "code": transferErr.Code})
+		case errors.Is(err, repositories.ErrAccountNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Transfer failed"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, transactionResponse(tx))
+}
+
+func transactionResponse(tx *models.Transaction) gin.H {
+	return gin.H{
+		"id":          tx.ID.Hex(),
+		"type":        tx.Type,
+		"fromAccount": tx.FromAccount.Hex(),
+		"toAccount":   tx.ToAccount.Hex(),
+		"amount":      tx.Amount,
+		"fee":         tx.Fee,
+		"description": tx.Description,
+		"status":      tx.Status,
+		"createdAt":   tx.CreatedAt,
+	}
+}
+
+// limitErrorResponse tells the client which KYC tier would allow the rejected operation
+func limitErrorResponse(err *services.LimitError) gin.H {
+	body := gin.H{
+		"error":         err.Message,
+		"code":          err.Code,
+		"current_tier":  err.CurrentTier,
+		"required_tier": nil,
+	}
+	if err.RequiredTier != services.NoTierUnlocks {
+		body["required_tier"] = err.RequiredTier
+	}
+	if err.Limit != nil {
+		body["limit"] = *err.Limit
+	}
+	return body
+}
@@ -29,26 +29,11 @@ func (c *UserController) GetProfile(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"id":        user.ID.Hex(),
-		"email":     user.Email,
-		"kycStatus": user.KYCStatus,
-		"createdAt": user.CreatedAt,
+		"id":             user.ID.Hex(),
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"kycStatus":      user.KYCStatus,
+		"kyc_tier":       user.KYCTier,
+		"createdAt":      user.CreatedAt,
 	})
 }
-
-func (c *UserController) InitiateKYC(ctx *gin.Context) {
-	userID, exists := ctx.Get("userID")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	// In a real app, you would process KYC documents here
-	_, err := c.userService.UpdateKYCStatus(userID.(string), "pending")
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	ctx.JSON(http.StatusOK, gin.H{"message": "KYC verification initiated"})
-}
@@ -0,0 +1,120 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrEmptyEntry        = errors.New("journal entry needs at least two postings")
+	ErrZeroPosting       = errors.New("posting amount cannot be zero")
+	ErrUnknownAccount    = errors.New("ledger account not found")
+	ErrCurrencyMismatch  = errors.New("posting currency does not match account currency")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// Ledger persists journal entries and keeps account balances in step with them.
+type Ledger interface {
+	// Post validates the entry, records it and applies every posting to its account
+	// balance, all or nothing. Wallet accounts are never overdrawn.
+	Post(ctx context.Context, entry *JournalEntry) (*JournalEntry, error)
+	// SystemAccount returns the named internal account for a currency, creating it on first use.
+	SystemAccount(ctx context.Context, name, currency string) (*models.Account, error)
+	// BalanceAt derives an account balance from every posting recorded up to and including at.
+	BalanceAt(ctx context.Context, accountID primitive.ObjectID, at time.Time) (money.Money, error)
+	// Reconcile checks the stored balance of an account against the postings that built it.
+	Reconcile(ctx context.Context, accountID primitive.ObjectID) (*Reconciliation, error)
+	// Entries lists the journal entries touching an account, newest first.
+	Entries(ctx context.Context, accountID primitive.ObjectID, limit int64) ([]JournalEntry, error)
+}
+
+// Posting moves Amount into (positive) or out of (negative) a single account.
+type Posting struct {
+	AccountID primitive.ObjectID `bson:"account_id"`
+	Amount    money.Money        `bson:"amount"`
+}
+
+// JournalEntry is an immutable group of postings that must sum to zero in every currency.
+type JournalEntry struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Reference   string             `bson:"reference"` // e.g. the transaction ID that caused the entry
+	Description string             `bson:"description"`
+	Postings    []Posting          `bson:"postings"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+// UnbalancedError reports the currency whose postings do not net to zero.
+type UnbalancedError struct {
+	Currency string
+	Sum      int64 // minor units
+}
+
+func (e *UnbalancedError) Error() string {
+	return fmt.Sprintf("journal entry does not balance in %s (off by %d)", e.Currency, e.Sum)
+}
+
+// Validate checks the double-entry invariant without touching the database.
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return ErrEmptyEntry
+	}
+
+	sums := map[string]money.Money{}
+	currencies := []string{}
+	for _, p := range e.Postings {
+		if !money.IsSupported(p.Amount.Currency) {
+			return money.ErrUnknownCurrency
+		}
+		if p.Amount.IsZero() {
+			return ErrZeroPosting
+		}
+
+		sum, seen := sums[p.Amount.Currency]
+		if !seen {
+			currencies = append(currencies, p.Amount.Currency)
+			sum = money.Zero(p.Amount.Currency)
+		}
+		sum, err := sum.Add(p.Amount)
+		if err != nil {
+			return err
+		}
+		sums[p.Amount.Currency] = sum
+	}
+
+	for _, currency := range currencies {
+		if !sums[currency].IsZero() {
+			return &UnbalancedError{Currency: currency, Sum: sums[currency].Amount}
+		}
+	}
+	return nil
+}
+
+// Transfer builds the two postings that move amount from one account to another.
+func Transfer(from, to primitive.ObjectID, amount money.Money) ([]Posting, error) {
+	debit, err := amount.Neg()
+	if err != nil {
+		return nil, err
+	}
+	return []Posting{
+		{AccountID: from, Amount: debit},
+		{AccountID: to, Amount: amount},
+	}, nil
+}
+
+// Reconciliation compares the balance stored on an account with the one derived from its postings.
+type Reconciliation struct {
+	AccountID      primitive.ObjectID
+	StoredBalance  money.Money
+	DerivedBalance money.Money
+	AsOf           time.Time
+}
+
+func (r *Reconciliation) Balanced() bool {
+	return r.StoredBalance == r.DerivedBalance
+}
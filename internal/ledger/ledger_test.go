@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidate(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	usd := func(amount string) money.Money { return money.MustParse(amount, "USD") }
+	eur := func(amount string) money.Money { return money.MustParse(amount, "EUR") }
+
+	cases := []struct {
+		name     string
+		postings []Posting
+		err      error
+	}{
+		{"balanced pair", []Posting{{a, usd("-10.00")}, {b, usd("10.00")}}, nil},
+		{"balanced split", []Posting{{a, usd("-10.00")}, {b, usd("7.50")}, {c, usd("2.50")}}, nil},
+		{"balanced per currency", []Posting{{a, usd("-10.00")}, {b, usd("10.00")}, {b, eur("-9.20")}, {c, eur("9.20")}}, nil},
+		{"single posting", []Posting{{a, usd("10.00")}}, ErrEmptyEntry},
+		{"zero posting", []Posting{{a, usd("0")}, {b, usd("0")}}, ErrZeroPosting},
+		{"unknown currency", []Posting{{a, money.Money{Amount: -1, Currency: "XXX"}}, {b, money.Money{Amount: 1, Currency: "XXX"}}}, money.ErrUnknownCurrency},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := (&JournalEntry{Postings: c.postings}).Validate()
+			if !errors.Is(err, c.err) {
+				t.Fatalf("Validate() = %v, want %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsUnbalancedEntries(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	cases := []struct {
+		name     string
+		postings []Posting
+		currency string
+		sum      int64
+	}{
+		{"off by a cent", []Posting{{a, money.MustParse("-10.00", "USD")}, {b, money.MustParse("10.01", "USD")}}, "USD", 1},
+		{"currencies netted against each other", []Posting{{a, money.MustParse("-10.00", "USD")}, {b, money.MustParse("10.00", "EUR")}}, "USD", -1000},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := (&JournalEntry{Postings: c.postings}).Validate()
+			var unbalanced *UnbalancedError
+			if !errors.As(err, &unbalanced) {
+				t.Fatalf("Validate() = %v, want an UnbalancedError", err)
+			}
+			if unbalanced.Currency != c.currency || unbalanced.Sum != c.sum {
+				t.Fatalf("got %s off by %d, want %s off by %d", unbalanced.Currency, unbalanced.Sum, c.currency, c.sum)
+			}
+		})
+	}
+}
+
+func TestTransferBalances(t *testing.T) {
+	postings, err := Transfer(primitive.NewObjectID(), primitive.NewObjectID(), money.MustParse("12.34", "USD"))
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if err := (&JournalEntry{Postings: postings}).Validate(); err != nil {
+		t.Fatalf("Transfer postings do not validate: %v", err)
+	}
+	if _, err := Transfer(primitive.NewObjectID(), primitive.NewObjectID(), money.Money{Amount: -1 << 63, Currency: "USD"}); !errors.Is(err, money.ErrOverflow) {
+		t.Fatalf("Transfer of the most negative amount = %v, want ErrOverflow", err)
+	}
+}
@@ -0,0 +1,235 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoLedger keeps journal entries in one collection and applies them to the balances in
+// the accounts collection.
+type MongoLedger struct {
+	client   *mongo.Client
+	entries  *mongo.Collection
+	accounts *mongo.Collection
+}
+
+func NewLedger(db *mongo.Database, entriesCollection, accountsCollection string) *MongoLedger {
+	return &MongoLedger{
+		client:   db.Client(),
+		entries:  db.Collection(entriesCollection),
+		accounts: db.Collection(accountsCollection),
+	}
+}
+
+// Post validates the entry, records it and applies every posting to its account balance.
+// If ctx already carries a Mongo session transaction the work joins it, otherwise Post
+// runs in its own transaction so an entry is never half applied.
+func (l *MongoLedger) Post(ctx context.Context, entry *JournalEntry) (*JournalEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	if mongo.SessionFromContext(ctx) != nil {
+		return entry, l.apply(ctx, entry)
+	}
+
+	session, err := l.client.StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, l.apply(sc, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (l *MongoLedger) apply(ctx context.Context, entry *JournalEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	for _, p := range entry.Postings {
+		filter := bson.M{"_id": p.AccountID, "balance.currency": p.Amount.Currency}
+		if p.Amount.IsNegative() {
+			// wallet accounts can never be overdrawn; system accounts may go negative
+			filter["$or"] = bson.A{
+				bson.M{"type": models.AccountTypeSystem},
+				bson.M{"balance.minor_units": bson.M{"$gte": -p.Amount.Amount}},
+			}
+		}
+
+		update := bson.M{
+			"$inc": bson.M{"balance.minor_units": p.Amount.Amount},
+			"$set": bson.M{"updated_at": entry.CreatedAt},
+		}
+		res, err := l.accounts.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return l.explainRejectedPosting(ctx, p)
+		}
+	}
+
+	_, err := l.entries.InsertOne(ctx, entry)
+	return err
+}
+
+func (l *MongoLedger) explainRejectedPosting(ctx context.Context, p Posting) error {
+	account, err := l.account(ctx, p.AccountID)
+	if err != nil {
+		return err
+	}
+	if account.Currency != p.Amount.Currency {
+		return ErrCurrencyMismatch
+	}
+	return ErrInsufficientFunds
+}
+
+// SystemAccount returns the named internal account for a currency, creating it on first use.
+func (l *MongoLedger) SystemAccount(ctx context.Context, name, currency string) (*models.Account, error) {
+	now := time.Now()
+	filter := bson.M{"type": models.AccountTypeSystem, "name": name, "currency": currency}
+	update := bson.M{"$setOnInsert": bson.M{
+		"_id":        primitive.NewObjectID(),
+		"user_id":    primitive.NilObjectID,
+		"type":       models.AccountTypeSystem,
+		"name":       name,
+		"currency":   currency,
+		"balance":    money.Zero(currency),
+		"is_active":  true,
+		"created_at": now,
+		"updated_at": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var account models.Account
+	err := l.accounts.FindOneAndUpdate(ctx, filter, update, opts).Decode(&account)
+	if mongo.IsDuplicateKeyError(err) {
+		// another caller created it first; the unique index turned our insert away
+		err = l.accounts.FindOne(ctx, filter).Decode(&account)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// BalanceAt derives an account balance from every posting recorded up to and including at.
+func (l *MongoLedger) BalanceAt(ctx context.Context, accountID primitive.ObjectID, at time.Time) (money.Money, error) {
+	account, err := l.account(ctx, accountID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return l.sumPostings(ctx, account, at)
+}
+
+func (l *MongoLedger) sumPostings(ctx context.Context, account *models.Account, at time.Time) (money.Money, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"postings.account_id": account.ID, "created_at": bson.M{"$lte": at}}}},
+		{{Key: "$unwind", Value: "$postings"}},
+		{{Key: "$match", Value: bson.M{"postings.account_id": account.ID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "balance": bson.M{"$sum": "$postings.amount.minor_units"}}}},
+	}
+
+	cursor, err := l.entries.Aggregate(ctx, pipeline)
+	if err != nil {
+		return money.Money{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Balance int64 `bson:"balance"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return money.Money{}, err
+	}
+
+	balance := money.Zero(account.Currency)
+	if len(result) > 0 {
+		balance.Amount = result[0].Balance
+	}
+	return balance, nil
+}
+
+func (l *MongoLedger) account(ctx context.Context, accountID primitive.ObjectID) (*models.Account, error) {
+	var account models.Account
+	err := l.accounts.FindOne(ctx, bson.M{"_id": accountID}).Decode(&account)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUnknownAccount
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Reconcile checks the stored balance of an account against the postings that built it.
+// Both are read from one snapshot, so an entry posted in between cannot show up in only
+// one of them and pass for a mismatch.
+func (l *MongoLedger) Reconcile(ctx context.Context, accountID primitive.ObjectID) (*Reconciliation, error) {
+	session, err := l.client.StartSession(options.Session().SetSnapshot(true))
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var reconciliation *Reconciliation
+	err = mongo.WithSession(ctx, session, func(sc mongo.SessionContext) error {
+		account, err := l.account(sc, accountID)
+		if err != nil {
+			return err
+		}
+
+		// every entry in the snapshot counts; created_at is stamped before commit
+		asOf := time.Now()
+		derived, err := l.sumPostings(sc, account, farFuture)
+		if err != nil {
+			return err
+		}
+
+		reconciliation = &Reconciliation{
+			AccountID:      accountID,
+			StoredBalance:  account.Balance,
+			DerivedBalance: derived,
+			AsOf:           asOf,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reconciliation, nil
+}
+
+// farFuture bounds a sum that should include every entry visible to the read.
+var farFuture = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// Entries lists the journal entries touching an account, newest first.
+func (l *MongoLedger) Entries(ctx context.Context, accountID primitive.ObjectID, limit int64) ([]JournalEntry, error) {
+	entries := []JournalEntry{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit)
+	cursor, err := l.entries.Find(ctx, bson.M{"postings.account_id": accountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged. A key matches when it
+// contains one of these, so "refresh_token" and "new_password" are covered too.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization", "cookie", "api_key", "apikey", "recovery", "totp", "otp", "code"}
+
+var (
+	jwtPattern        = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern     = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	queryParamPattern = regexp.MustCompile(`(?i)\b(password|token|secret|code|api_key|key)=[^&\s"]+`)
+	jsonFieldPattern  = regexp.MustCompile(`(?i)"([a-z_]*(?:password|token|secret|code)[a-z_]*)"\s*:\s*"[^"]*"`)
+	emailPattern      = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+)
+
+// Redact masks emails, bearer and JWT tokens, and secret-looking query parameters or
+// JSON fields in free text. Emails keep their first letter and domain, which is usually
+// enough to tell users apart while debugging.
+func Redact(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = queryParamPattern.ReplaceAllString(s, "$1="+redacted)
+	s = jsonFieldPattern.ReplaceAllString(s, `"$1":"`+redacted+`"`)
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	return s
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr is the slog ReplaceAttr hook: sensitive keys lose their value entirely,
+// strings and errors are scrubbed with Redact.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.SourceKey) {
+		return a
+	}
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
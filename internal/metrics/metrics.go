@@ -0,0 +1,127 @@
+// Package metrics holds the Prometheus collectors for the wallet: HTTP traffic, the
+// MongoDB connection pool and business counters recorded by the services.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "wallet"
+
+// Login results for Metrics.Login.
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+	LoginThrottled = "throttled"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpDuration *prometheus.HistogramVec
+
+	registrations  prometheus.Counter
+	logins         *prometheus.CounterVec
+	transfers      *prometheus.CounterVec
+	transferVolume *prometheus.CounterVec
+	fxConversions  *prometheus.CounterVec
+	kycTransitions *prometheus.CounterVec
+}
+
+// New builds the collectors on a fresh registry, together with the Go runtime and
+// process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "Users registered.",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Password logins by result: success, failure or throttled.",
+		}, []string{"result"}),
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_total",
+			Help:      "Completed transfers by currency.",
+		}, []string{"currency"}),
+		transferVolume: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfer_volume_minor_units_total",
+			Help:      "Amount moved by completed transfers, in minor units of the currency.",
+		}, []string{"currency"}),
+		fxConversions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fx_conversions_total",
+			Help:      "Completed currency conversions by currency pair.",
+		}, []string{"sell", "buy"}),
+		kycTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "kyc_transitions_total",
+			Help:      "KYC status changes by the status moved to.",
+		}, []string{"status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.registrations,
+		m.logins,
+		m.transfers,
+		m.transferVolume,
+		m.fxConversions,
+		m.kycTransitions,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Register adds further collectors, such as the Mongo pool monitor.
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) Registration() {
+	m.registrations.Inc()
+}
+
+func (m *Metrics) Login(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) Transfer(currency string, minorUnits int64) {
+	m.transfers.WithLabelValues(currency).Inc()
+	m.transferVolume.WithLabelValues(currency).Add(float64(minorUnits))
+}
+
+func (m *Metrics) FXConversion(sellCurrency, buyCurrency string) {
+	m.fxConversions.WithLabelValues(sellCurrency, buyCurrency).Inc()
+}
+
+func (m *Metrics) KYCTransition(status string) {
+	m.kycTransitions.WithLabelValues(status).Inc()
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoPoolMonitor tracks the driver's connection pool. Hand Monitor() to
+// options.Client().SetPoolMonitor and register the monitor with Metrics.Register.
+type MongoPoolMonitor struct {
+	maxSize         *prometheus.GaugeVec
+	open            *prometheus.GaugeVec
+	inUse           *prometheus.GaugeVec
+	checkouts       *prometheus.CounterVec
+	checkoutFailure *prometheus.CounterVec
+	checkoutWait    *prometheus.HistogramVec
+	cleared         *prometheus.CounterVec
+}
+
+func NewMongoPoolMonitor() *MongoPoolMonitor {
+	return &MongoPoolMonitor{
+		maxSize: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_max_connections",
+			Help:      "Configured maximum size of the MongoDB pool, per server.",
+		}, []string{"address"}),
+		open: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_connections",
+			Help:      "Open connections in the MongoDB pool, per server.",
+		}, []string{"address"}),
+		inUse: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_connections_in_use",
+			Help:      "Connections currently checked out of the MongoDB pool, per server.",
+		}, []string{"address"}),
+		checkouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_checkouts_total",
+			Help:      "Successful connection checkouts from the MongoDB pool.",
+		}, []string{"address"}),
+		checkoutFailure: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_checkout_failures_total",
+			Help:      "Failed connection checkouts by reason, such as a timeout waiting for a free connection.",
+		}, []string{"address", "reason"}),
+		checkoutWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_checkout_wait_seconds",
+			Help:      "Time spent waiting for a connection from the MongoDB pool.",
+			Buckets:   []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		}, []string{"address"}),
+		cleared: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "mongo_pool_cleared_total",
+			Help:      "Times the MongoDB pool was cleared after a server error.",
+		}, []string{"address"}),
+	}
+}
+
+func (p *MongoPoolMonitor) Describe(ch chan<- *prometheus.Desc) {
+	p.maxSize.Describe(ch)
+	p.open.Describe(ch)
+	p.inUse.Describe(ch)
+	p.checkouts.Describe(ch)
+	p.checkoutFailure.Describe(ch)
+	p.checkoutWait.Describe(ch)
+	p.cleared.Describe(ch)
+}
+
+func (p *MongoPoolMonitor) Collect(ch chan<- prometheus.Metric) {
+	p.maxSize.Collect(ch)
+	p.open.Collect(ch)
+	p.inUse.Collect(ch)
+	p.checkouts.Collect(ch)
+	p.checkoutFailure.Collect(ch)
+	p.checkoutWait.Collect(ch)
+	p.cleared.Collect(ch)
+}
+
+// Monitor returns the driver hook that feeds the collectors.
+func (p *MongoPoolMonitor) Monitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: p.handle}
+}
+
+func (p *MongoPoolMonitor) handle(e *event.PoolEvent) {
+	switch e.Type {
+	case event.PoolCreated:
+		if e.PoolOptions != nil {
+			p.maxSize.WithLabelValues(e.Address).Set(float64(e.PoolOptions.MaxPoolSize))
+		}
+	case event.ConnectionCreated:
+		p.open.WithLabelValues(e.Address).Inc()
+	case event.ConnectionClosed:
+		p.open.WithLabelValues(e.Address).Dec()
+	case event.GetSucceeded:
+		p.inUse.WithLabelValues(e.Address).Inc()
+		p.checkouts.WithLabelValues(e.Address).Inc()
+		p.checkoutWait.WithLabelValues(e.Address).Observe(e.Duration.Seconds())
+	case event.ConnectionReturned:
+		p.inUse.WithLabelValues(e.Address).Dec()
+	case event.GetFailed:
+		p.checkoutFailure.WithLabelValues(e.Address, e.Reason).Inc()
+	case event.PoolCleared:
+		p.cleared.WithLabelValues(e.Address).Inc()
+	}
+}
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminActionKey is the gin context key under which the audit middleware hands admin
+// handlers a *models.AdminAction to describe what they do. Handlers that change state
+// have their service store it in the same transaction as the change.
+const AdminActionKey = "adminAction"
+
+type AdminAuditMiddleware struct {
+	adminService *services.AdminService
+}
+
+func NewAdminAuditMiddleware(adminService *services.AdminService) *AdminAuditMiddleware {
+	return &AdminAuditMiddleware{adminService: adminService}
+}
+
+// Record logs every request that reaches an admin handler, successful or not, with the
+// staff member who made it. Actions already stored alongside the change they made are not
+// stored again. Must run after Authenticate and RequireRole.
+func (m *AdminAuditMiddleware) Record(c *gin.Context) {
+	staffID, err := primitive.ObjectIDFromHex(c.GetString("userID"))
+	if err != nil {
+		c.Next()
+		return
+	}
+
+	action := &models.AdminAction{
+		StaffID:   staffID,
+		StaffRole: c.GetString("staffRole"),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		IP:        c.ClientIP(),
+	}
+	c.Set(AdminActionKey, action)
+
+	c.Next()
+
+	if !action.ID.IsZero() {
+		return
+	}
+	if action.Action == "" {
+		action.Action = c.Request.Method + " " + c.FullPath()
+	}
+	action.Status = c.Writer.Status()
+
+	// the client may already be gone, the record must still be written
+	m.adminService.Record(context.WithoutCancel(c.Request.Context()), action)
+}
@@ -1,19 +1,31 @@
 package middlewares
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/models"
 	"github.com/samoray1998/fintech-wallet/internal/services"
 )
 
+const TwoFactorCodeHeader = "X-2FA-Code"
+
 type AuthMiddleware struct {
-	authService *services.AuthService
+	authService      *services.AuthService
+	twoFactorService *services.TwoFactorService
+	emailService     *services.EmailVerificationService
 }
 
-func NewAuthMiddleware(authService *services.AuthService) *AuthMiddleware {
-	return &AuthMiddleware{authService: authService}
+func NewAuthMiddleware(authService *services.AuthService, twoFactorService *services.TwoFactorService, emailService *services.EmailVerificationService) *AuthMiddleware {
+	return &AuthMiddleware{
+		authService:      authService,
+		twoFactorService: twoFactorService,
+		emailService:     emailService,
+	}
 }
 
 // Correct implementation as a direct gin.HandlerFunc
@@ -30,14 +42,84 @@ func (m *AuthMiddleware) Authenticate(c *gin.Context) {
 		return
 	}
 
-	claims, err := m.authService.ValidateToken(token)
+	claims, err := m.authService.Authenticate(c.Request.Context(), token)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 		return
 	}
 
 	// Set user information in context
-	c.Set("userID", claims["user_id"])
-	c.Set("userEmail", claims["email"])
+	c.Set("userID", claims.UserID)
+	c.Set("userEmail", claims.Email)
+	c.Set("claims", claims)
+	c.Next()
+}
+
+// RequireSecondFactor guards sensitive operations: users with 2FA enabled must send a
+// fresh code in the X-2FA-Code header. Must run after Authenticate.
+func (m *AuthMiddleware) RequireSecondFactor(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	err := m.twoFactorService.CheckSensitiveOperation(c.Request.Context(), userID, c.GetHeader(TwoFactorCodeHeader))
+	if err != nil {
+		var throttled *services.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(throttled.RetryAfter.Seconds())), 10))
+			status, code := http.StatusTooManyRequests, "2fa_throttled"
+			if throttled.Locked {
+				status, code = http.StatusLocked, "2fa_locked"
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": throttled.Error(), "code": code})
+		case errors.Is(err, services.ErrTwoFactorRequired):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "2fa_required"})
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "2fa_invalid"})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor code"})
+		}
+		return
+	}
 	c.Next()
 }
+
+// RequireVerifiedEmail keeps users who have not confirmed their address away from
+// opening accounts and moving money. Must run after Authenticate.
+func (m *AuthMiddleware) RequireVerifiedEmail(c *gin.Context) {
+	err := m.emailService.CheckVerified(c.GetString("userID"))
+	if err != nil {
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "email_unverified"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email verification"})
+		return
+	}
+	c.Next()
+}
+
+// RequireRole lets through only users holding one of roles. The role is read from the
+// database on every request so that revoking it takes effect immediately. Must run after
+// Authenticate.
+func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := m.authService.UserRepo.FindByID(c.GetString("userID"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		role := user.Role
+		if role == "" {
+			role = models.RoleUser
+		}
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Set("staffRole", role)
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	}
+}
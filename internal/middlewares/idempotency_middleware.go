@@ -0,0 +1,150 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+type IdempotencyMiddleware struct {
+	repo repositories.IdempotencyRepository
+}
+
+func NewIdempotencyMiddleware(repo repositories.IdempotencyRepository) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{repo: repo}
+}
+
+// Handle replays the stored response when a request is retried with the same Idempotency-Key.
+// Requests without the header pass straight through. On authenticated routes it must run
+// after Authenticate so keys are scoped to the calling user.
+func (m *IdempotencyMiddleware) Handle(c *gin.Context) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentRequestBytes))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	scope := "anonymous"
+	if userID, exists := c.Get("userID"); exists {
+		scope = fmt.Sprint(userID)
+	}
+
+	record := &models.IdempotencyRecord{
+		ID:          hashParts(scope, key),
+		Fingerprint: hashParts(c.Request.Method, c.FullPath(), string(body)),
+	}
+
+	existing, err := m.repo.Reserve(c.Request.Context(), record)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+		return
+	}
+	if existing != nil {
+		m.replay(c, existing, record.Fingerprint)
+		return
+	}
+
+	// a panicking handler skips everything after c.Next, which would leave the key reserved
+	// until it expires; free it before gin.Recovery answers with a 500
+	defer func() {
+		if p := recover(); p != nil {
+			m.release(c, record.ID)
+			panic(p)
+		}
+	}()
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	c.Writer = recorder
+	c.Next()
+
+	// server errors and auth rejections are not remembered so the client can safely try again
+	if !replayable(c.Writer.Status()) {
+		m.release(c, record.ID)
+		return
+	}
+
+	err = m.repo.Complete(c.Request.Context(), record.ID, c.Writer.Status(), c.Writer.Header().Get("Content-Type"), recorder.body.Bytes())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to store idempotent response", "error", err)
+	}
+}
+
+func (m *IdempotencyMiddleware) release(c *gin.Context, id string) {
+	if err := m.repo.Release(c.Request.Context(), id); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to release idempotency key", "error", err)
+	}
+}
+
+func (m *IdempotencyMiddleware) replay(c *gin.Context, existing *models.IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if existing.Status != models.IdempotencyStatusCompleted {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(existing.ResponseStatus, existing.ContentType, existing.ResponseBody)
+	c.Abort()
+}
+
+func replayable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+func hashParts(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder tees everything written to the client into body
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/repositories/memory"
+)
+
+func TestIdempotencyKeyIsReleasedWhenHandlerPanics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	idempotency := NewIdempotencyMiddleware(memory.NewIdempotencyRepo(clock.System))
+
+	calls := 0
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.POST("/transfers", idempotency.Handle, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"amount":"1.00"}`))
+		req.Header.Set(IdempotencyKeyHeader, "retry-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(); code != http.StatusInternalServerError {
+		t.Fatalf("first attempt = %d, want 500", code)
+	}
+	if code := send(); code != http.StatusCreated {
+		t.Fatalf("retry after a panic = %d, want 201", code)
+	}
+}
@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+)
+
+type MetricsMiddleware struct {
+	metrics *metrics.Metrics
+	handler http.Handler
+	token   string
+}
+
+func NewMetricsMiddleware(walletMetrics *metrics.Metrics, token string) *MetricsMiddleware {
+	return &MetricsMiddleware{
+		metrics: walletMetrics,
+		handler: walletMetrics.Handler(),
+		token:   token,
+	}
+}
+
+// Observe times every request and labels it with the route template rather than the
+// raw path, so IDs in URLs do not blow up the number of series.
+func (m *MetricsMiddleware) Observe(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	m.metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+}
+
+// Serve exposes the metrics for scraping. With a token configured, the scraper must
+// send it as a bearer token.
+func (m *MetricsMiddleware) Serve(c *gin.Context) {
+	if m.token != "" {
+		given := c.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+m.token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+	}
+	m.handler.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy is a token bucket: Requests tokens refill evenly over Per, and at most
+// Burst can be saved up.
+type RateLimitPolicy struct {
+	Name     string
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (p RateLimitPolicy) rate() float64 {
+	return float64(p.Requests) / p.Per.Seconds()
+}
+
+// RateLimitPolicies are the limits applied to each group of routes.
+type RateLimitPolicies struct {
+	Default RateLimitPolicy
+	Auth    RateLimitPolicy // login, register and other credential endpoints
+}
+
+// RateLimitStore takes one token from the bucket behind key, returning the tokens left
+// and whether the request is allowed. rate is in tokens per second.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (float64, bool, error)
+}
+
+type RateLimiter struct {
+	store RateLimitStore
+}
+
+func NewRateLimiter(store RateLimitStore) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+// Limit enforces policy per authenticated user, or per client IP before authentication.
+// On the private routes it must run after Authenticate. A policy with no requests
+// disables limiting.
+func (l *RateLimiter) Limit(policy RateLimitPolicy) gin.HandlerFunc {
+	if policy.Requests <= 0 || policy.Per <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	rate := policy.rate()
+
+	return func(c *gin.Context) {
+		subject := "ip:" + c.ClientIP()
+		if userID := c.GetString("userID"); userID != "" {
+			subject = "user:" + userID
+		}
+
+		remaining, allowed, err := l.store.Take(c.Request.Context(), policy.Name+":"+subject, rate, policy.Burst, time.Now())
+		if err != nil {
+			// an unavailable store should not take the whole API down with it
+			slog.WarnContext(c.Request.Context(), "Rate limiter unavailable, allowing request", "error", err)
+			c.Next()
+			return
+		}
+
+		untilFull := time.Duration((float64(policy.Burst) - remaining) / rate * float64(time.Second))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Floor(remaining))))
+		c.Header("X-RateLimit-Reset", fmt.Sprint(int64(math.Ceil(untilFull.Seconds()))))
+
+		if !allowed {
+			untilNext := (1 - remaining) / rate
+			c.Header("Retry-After", fmt.Sprint(int64(math.Ceil(untilNext))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// MemoryRateLimitStore keeps buckets in process memory. Each instance enforces its own
+// limits, so use the Mongo store when running more than one.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*memoryBucket{}}
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(burst), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	if elapsed := now.Sub(bucket.updatedAt).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(float64(burst), bucket.tokens+elapsed*rate)
+	}
+	bucket.updatedAt = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	bucket.fullAt = now.Add(time.Duration((float64(burst) - bucket.tokens) / rate * float64(time.Second)))
+
+	s.evictFull(now)
+	return bucket.tokens, allowed, nil
+}
+
+// evictFull drops buckets that have refilled completely, since a fresh bucket behaves the
+// same; callers hold s.mu.
+func (s *MemoryRateLimitStore) evictFull(now time.Time) {
+	if len(s.buckets) < 10000 {
+		return
+	}
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
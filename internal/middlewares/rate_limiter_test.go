@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newLimitedRouter allows one request per client, trusting X-Forwarded-For only from
+// trustedProxies as SetupRouter does.
+func newLimitedRouter(t *testing.T, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatalf("SetTrustedProxies: %v", err)
+	}
+	limiter := NewRateLimiter(NewMemoryRateLimitStore())
+	router.POST("/login", limiter.Limit(RateLimitPolicy{Name: "auth", Requests: 1, Per: time.Hour, Burst: 1}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func login(router *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSpoofedForwardedForDoesNotChangeBucket(t *testing.T) {
+	router := newLimitedRouter(t, nil)
+
+	if code := login(router, "203.0.113.7:5000", "198.51.100.1"); code != http.StatusNoContent {
+		t.Fatalf("first request = %d, want 204", code)
+	}
+	if code := login(router, "203.0.113.7:5000", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("request with a rotated X-Forwarded-For = %d, want 429", code)
+	}
+}
+
+func TestTrustedProxyForwardedForIsBelieved(t *testing.T) {
+	router := newLimitedRouter(t, []string{"10.0.0.0/8"})
+
+	if code := login(router, "10.0.0.5:5000", "198.51.100.1"); code != http.StatusNoContent {
+		t.Fatalf("first client = %d, want 204", code)
+	}
+	if code := login(router, "10.0.0.5:5000", "198.51.100.2"); code != http.StatusNoContent {
+		t.Fatalf("second client behind the proxy = %d, want 204", code)
+	}
+	if code := login(router, "10.0.0.5:5000", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("first client again = %d, want 429", code)
+	}
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID keeps client-supplied IDs short and free of anything that could forge
+// or break a log line.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID reuses the caller's X-Request-ID, or makes one up, echoes it in the response
+// and puts it in the request context so every log line of the request carries it.
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if !validRequestID.MatchString(id) {
+		id = primitive.NewObjectID().Hex()
+	}
+
+	c.Set("requestID", id)
+	c.Header(RequestIDHeader, id)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+	c.Next()
+}
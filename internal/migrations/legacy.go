@@ -0,0 +1,122 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The first User model had unterminated bson tags, so the driver ignored them and stored
+// most fields under the lowercased Go name. The user's ID went into "id" and the driver
+// generated a separate "_id", so tokens issued then carry the "id" value.
+var legacyUserFields = map[string]string{
+	"fullname":  "full_name",
+	"password":  "password_hash",
+	"createdat": "created_at",
+}
+
+// the baseline's password and KYC updates wrote these keys as well
+var legacyUpdatedAtFields = []string{"updatedat", "updatedAt", "updated_at"}
+
+// rewriteLegacyDocuments moves legacy users onto the current field names, re-keyed so
+// _id is the ID their tokens and accounts refer to. Mongo cannot change an _id in place,
+// so each one is inserted under the new _id and the old document removed; a rerun after a
+// crash between the two finds the insert already done and only removes the old document.
+func rewriteLegacyDocuments(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("users")
+	cursor, err := users.Find(ctx, bson.M{"id": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		oldID := doc["_id"]
+		user, err := rewriteLegacyUser(doc)
+		if err != nil {
+			return fmt.Errorf("user %v: %w", oldID, err)
+		}
+
+		if user["_id"] == oldID {
+			if _, err := users.ReplaceOne(ctx, bson.M{"_id": oldID}, user); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := users.InsertOne(ctx, user); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("user %v: %w", oldID, err)
+		}
+		if _, err := users.DeleteOne(ctx, bson.M{"_id": oldID}); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	// nothing in the baseline wrote transactions, but its model had the same problem
+	_, err = db.Collection("transactions").UpdateMany(ctx,
+		bson.M{"fromaccount": bson.M{"$exists": true}, "from_account": bson.M{"$exists": false}},
+		bson.M{"$rename": bson.M{"fromaccount": "from_account"}})
+	return err
+}
+
+func rewriteLegacyUser(doc bson.M) (bson.M, error) {
+	id, ok := doc["id"].(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("id is %T, not an ObjectID", doc["id"])
+	}
+
+	user := bson.M{}
+	for key, value := range doc {
+		user[key] = value
+	}
+	delete(user, "id")
+	user["_id"] = id
+
+	for legacy, current := range legacyUserFields {
+		value, ok := user[legacy]
+		delete(user, legacy)
+		if _, exists := user[current]; ok && !exists {
+			user[current] = value
+		}
+	}
+	// the baseline password update stored the bcrypt hash as binary
+	if hash, ok := user["password_hash"].(primitive.Binary); ok {
+		user["password_hash"] = string(hash.Data)
+	}
+
+	var updatedAt time.Time
+	for _, key := range legacyUpdatedAtFields {
+		if t, ok := legacyTime(user[key]); ok && t.After(updatedAt) {
+			updatedAt = t
+		}
+		delete(user, key)
+	}
+	if !updatedAt.IsZero() {
+		user["updated_at"] = updatedAt
+	}
+	return user, nil
+}
+
+// legacyTime reads a timestamp stored as a date or, as the baseline KYC update did, as
+// Unix seconds.
+func legacyTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return v.Time(), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int32:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRewriteLegacyUser(t *testing.T) {
+	generated, id := primitive.NewObjectID(), primitive.NewObjectID()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	passwordChanged := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	kycChanged := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	user, err := rewriteLegacyUser(bson.M{
+		"_id":        generated,
+		"id":         id,
+		"fullname":   "Ada Lovelace",
+		"password":   primitive.Binary{Data: []byte("$2a$10$hash")},
+		"email":      "ada@example.com",
+		"kyc_status": "unverified",
+		"createdat":  primitive.NewDateTimeFromTime(created),
+		"updatedat":  primitive.NewDateTimeFromTime(created),
+		"updatedAt":  primitive.NewDateTimeFromTime(passwordChanged),
+		"updated_at": kycChanged.Unix(),
+	})
+	if err != nil {
+		t.Fatalf("rewriteLegacyUser: %v", err)
+	}
+
+	want := bson.M{
+		"_id":           id,
+		"full_name":     "Ada Lovelace",
+		"password_hash": "$2a$10$hash",
+		"email":         "ada@example.com",
+		"kyc_status":    "unverified",
+		"created_at":    primitive.NewDateTimeFromTime(created),
+		"updated_at":    kycChanged,
+	}
+	if len(user) != len(want) {
+		t.Fatalf("got fields %v, want %v", user, want)
+	}
+	for key, value := range want {
+		got := user[key]
+		if ts, ok := got.(time.Time); ok {
+			got = ts.UTC()
+		}
+		if got != value {
+			t.Errorf("%s = %v, want %v", key, user[key], value)
+		}
+	}
+}
+
+func TestRewriteLegacyUserKeepsCurrentFields(t *testing.T) {
+	user, err := rewriteLegacyUser(bson.M{
+		"_id":           primitive.NewObjectID(),
+		"id":            primitive.NewObjectID(),
+		"password":      "old",
+		"password_hash": "new",
+	})
+	if err != nil {
+		t.Fatalf("rewriteLegacyUser: %v", err)
+	}
+	if user["password_hash"] != "new" {
+		t.Fatalf("password_hash = %v, want the current value", user["password_hash"])
+	}
+	if _, ok := user["password"]; ok {
+		t.Fatal("legacy password field was kept")
+	}
+}
@@ -0,0 +1,401 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection is where applied versions are recorded.
+const Collection = "schema_migrations"
+
+// All is the schema in order. Append new migrations at the end with the next version;
+// never renumber or edit one that has shipped.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "rewrite users and transactions stored under the untagged baseline field names",
+		Up:          rewriteLegacyDocuments,
+	},
+	{
+		Version:     2,
+		Description: "backfill email_verified, kyc_tier and role on existing users",
+		Up:          backfillUserDefaults,
+	},
+	{
+		Version:     3,
+		Description: "lowercase users.email, unique index on it, index on users.kyc_reference",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			users := db.Collection("users")
+			if err := checkNoDuplicateEmails(ctx, users); err != nil {
+				return err
+			}
+			if err := normalizeEmails(ctx, users); err != nil {
+				return err
+			}
+			_, err := users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName("email_unique").SetUnique(true)},
+				{
+					Keys: bson.D{{Key: "kyc_reference", Value: 1}},
+					Options: options.Index().SetName("kyc_reference").
+						SetPartialFilterExpression(bson.M{"kyc_reference": bson.M{"$type": "string"}}),
+				},
+			})
+			return err
+		},
+		Down: dropIndexes("users", "email_unique", "kyc_reference"),
+	},
+	{
+		Version:     4,
+		Description: "unique index on wallet accounts by user_id and currency",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("accounts").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "currency", Value: 1}},
+				Options: options.Index().SetName("wallet_user_currency_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"type": models.AccountTypeWallet}),
+			})
+			return err
+		},
+		Down: dropIndexes("accounts", "wallet_user_currency_unique"),
+	},
+	{
+		Version:     5,
+		Description: "transaction indexes for account history, outgoing totals and the admin feed",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// the server created the from_account index itself before there were migrations;
+			// with the same keys and default name this is a no-op on those databases
+			_, err := db.Collection("transactions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "from_account", Value: 1}, {Key: "created_at", Value: -1}}},
+				{Keys: bson.D{{Key: "to_account", Value: 1}, {Key: "created_at", Value: -1}}},
+				{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			})
+			return err
+		},
+		Down: dropIndexes("transactions", "from_account_1_created_at_-1", "to_account_1_created_at_-1", "created_at_-1"),
+	},
+	{
+		Version:     6,
+		Description: "JSON schema validators on users, accounts and transactions",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// catch users an older server still running during the rollout wrote after
+			// version 1, which the users validator would otherwise reject on their next update
+			if err := rewriteLegacyDocuments(ctx, db); err != nil {
+				return err
+			}
+			for _, name := range []string{"users", "accounts", "transactions"} {
+				if err := setValidator(ctx, db, name, schemas[name]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"users", "accounts", "transactions"} {
+				if err := setValidator(ctx, db, name, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     7,
+		Description: "indexes on refresh_tokens, revoked_tokens, password_resets, login_attempts and rate_limits",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// the server created these itself before they moved here; with the same keys and
+			// options each build is a no-op on those databases
+			expiring := mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)}
+			return createIndexes(ctx, db, map[string][]mongo.IndexModel{
+				"refresh_tokens": {
+					{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+					{Keys: bson.D{{Key: "family_id", Value: 1}}},
+					{Keys: bson.D{{Key: "user_id", Value: 1}}},
+					expiring,
+				},
+				"revoked_tokens": {expiring},
+				"password_resets": {
+					{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+					{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+					// kept for a day past expiry so reset rate limiting can still count them
+					{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32((24 * time.Hour).Seconds()))},
+				},
+				"login_attempts": {expiring},
+				"rate_limits":    {expiring},
+			})
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexesIn(ctx, db, map[string][]string{
+				"refresh_tokens":  {"token_hash_1", "family_id_1", "user_id_1", "expires_at_1"},
+				"revoked_tokens":  {"expires_at_1"},
+				"password_resets": {"token_hash_1", "user_id_1_created_at_-1", "expires_at_1"},
+				"login_attempts":  {"expires_at_1"},
+				"rate_limits":     {"expires_at_1"},
+			})
+		},
+	},
+	{
+		Version:     8,
+		Description: "indexes on audit_events, admin_actions and kyc_cases",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db, map[string][]mongo.IndexModel{
+				// a unique seq is what lets concurrent audit writers detect that they raced
+				"audit_events": {
+					{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+					{Keys: bson.D{{Key: "subject_id", Value: 1}, {Key: "seq", Value: -1}}},
+					{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "seq", Value: -1}}},
+				},
+				"admin_actions": {
+					{Keys: bson.D{{Key: "staff_id", Value: 1}, {Key: "created_at", Value: -1}}},
+					{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+				},
+				// one open case per user, which also makes OpenCase race-free
+				"kyc_cases": {
+					{
+						Keys: bson.D{{Key: "user_id", Value: 1}},
+						Options: options.Index().SetUnique(true).
+							SetPartialFilterExpression(bson.M{"status": models.KYCCaseStatusOpen}),
+					},
+					{Keys: bson.D{{Key: "kyc_reference", Value: 1}}},
+				},
+			})
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndexesIn(ctx, db, map[string][]string{
+				"audit_events":  {"seq_1", "subject_id_1_seq_-1", "actor_id_1_seq_-1"},
+				"admin_actions": {"staff_id_1_created_at_-1", "target_id_1_created_at_-1"},
+				"kyc_cases":     {"user_id_1", "kyc_reference_1"},
+			})
+		},
+	},
+	{
+		Version:     9,
+		Description: "expire idempotency keys at their own expires_at, so IDEMPOTENCY_TTL needs no index rebuild",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			keys := db.Collection("idempotency_keys")
+			_, err := keys.UpdateMany(ctx, bson.M{"expires_at": bson.M{"$exists": false}}, mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"expires_at": bson.M{"$add": bson.A{"$created_at", legacyIdempotencyTTL.Milliseconds()}}}}},
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := keys.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			}); err != nil {
+				return err
+			}
+			return dropIndexes("idempotency_keys", "created_at_1")(ctx, db)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "created_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32(legacyIdempotencyTTL.Seconds())),
+			})
+			if err != nil {
+				return err
+			}
+			return dropIndexes("idempotency_keys", "expires_at_1")(ctx, db)
+		},
+	},
+	{
+		Version:     10,
+		Description: "unique index on system accounts by type, name and currency",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			accounts := db.Collection("accounts")
+			if err := checkNoDuplicateSystemAccounts(ctx, accounts); err != nil {
+				return err
+			}
+			// SystemAccount upserts on these fields; without the index two first conversions
+			// racing can each create a pool and split its ledger
+			_, err := accounts.Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "type", Value: 1}, {Key: "name", Value: 1}, {Key: "currency", Value: 1}},
+				Options: options.Index().SetName("system_name_currency_unique").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"type": models.AccountTypeSystem}),
+			})
+			return err
+		},
+		Down: dropIndexes("accounts", "system_name_currency_unique"),
+	},
+}
+
+// legacyIdempotencyTTL is the IDEMPOTENCY_TTL default, for keys stored before they
+// carried their own expiry.
+const legacyIdempotencyTTL = 24 * time.Hour
+
+// backfillUserDefaults writes out the values the code already assumes for fields missing
+// from users created before those fields existed, so validators can require them. There
+// is no Down: the filled-in values mean the same as the missing fields did.
+func backfillUserDefaults(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("users")
+	defaults := []struct {
+		filter bson.M
+		set    bson.M
+	}{
+		{bson.M{"email_verified": bson.M{"$exists": false}}, bson.M{"email_verified": false}},
+		{bson.M{"kyc_tier": bson.M{"$exists": false}}, bson.M{"kyc_tier": models.KYCTierEmail}},
+		{bson.M{"$or": bson.A{bson.M{"role": bson.M{"$exists": false}}, bson.M{"role": ""}}}, bson.M{"role": models.RoleUser}},
+	}
+	for _, d := range defaults {
+		if _, err := users.UpdateMany(ctx, d.filter, bson.M{"$set": d.set}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoDuplicateEmails fails with the offending addresses rather than letting the index
+// build fail with a bare duplicate key error. Addresses are compared as normalizeEmails
+// will store them.
+func checkNoDuplicateEmails(ctx context.Context, users *mongo.Collection) error {
+	cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": normalizedEmail, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$limit", Value: 10}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var duplicates []struct {
+		Email string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	emails := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		emails[i] = d.Email
+	}
+	return fmt.Errorf("users share an email address, merge or remove them first: %s", strings.Join(emails, ", "))
+}
+
+// checkNoDuplicateSystemAccounts fails with the pools that were already split, whose
+// postings have to be moved onto one account by hand before the index can be built.
+func checkNoDuplicateSystemAccounts(ctx context.Context, accounts *mongo.Collection) error {
+	cursor, err := accounts.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"type": models.AccountTypeSystem}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"name": "$name", "currency": "$currency"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$limit", Value: 10}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var duplicates []struct {
+		Key struct {
+			Name     string `bson:"name"`
+			Currency string `bson:"currency"`
+		} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		names[i] = d.Key.Name + " " + d.Key.Currency
+	}
+	return fmt.Errorf("system accounts are duplicated, merge them first: %s", strings.Join(names, ", "))
+}
+
+// normalizedEmail is models.NormalizeEmail as an aggregation expression.
+var normalizedEmail = bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}}
+
+// normalizeEmails stores every address the way the repositories look them up, since the
+// unique index compares them case-sensitively.
+func normalizeEmails(ctx context.Context, users *mongo.Collection) error {
+	_, err := users.UpdateMany(ctx,
+		bson.M{"email": bson.M{"$type": "string"}, "$expr": bson.M{"$ne": bson.A{"$email", normalizedEmail}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"email": normalizedEmail}}}})
+	return err
+}
+
+// createIndexes builds the given indexes on each collection.
+func createIndexes(ctx context.Context, db *mongo.Database, indexes map[string][]mongo.IndexModel) error {
+	for collection, list := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, list); err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// dropIndexesIn drops the named indexes from each collection.
+func dropIndexesIn(ctx context.Context, db *mongo.Database, indexes map[string][]string) error {
+	for collection, names := range indexes {
+		if err := dropIndexes(collection, names...)(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropIndexes(collection string, names ...string) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, name := range names {
+			_, err := db.Collection(collection).Indexes().DropOne(ctx, name)
+			if err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// setValidator installs schema on a collection, creating it if needed, or removes the
+// validator when schema is nil. Moderate validation leaves documents that are already
+// invalid alone until they are next updated.
+func setValidator(ctx context.Context, db *mongo.Database, name string, schema bson.M) error {
+	validator := bson.M{}
+	if schema != nil {
+		validator = bson.M{"$jsonSchema": schema}
+	}
+
+	existing, err := db.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		if schema == nil {
+			return nil
+		}
+		return db.CreateCollection(ctx, name, options.CreateCollection().
+			SetValidator(validator).SetValidationLevel("moderate").SetValidationAction("error"))
+	}
+
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+}
+
+// isNotFound reports whether err means the index or namespace is already gone.
+func isNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 26 || cmdErr.Code == 27 // NamespaceNotFound, IndexNotFound
+	}
+	return false
+}
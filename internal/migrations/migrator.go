@@ -0,0 +1,188 @@
+// Package migrations evolves the MongoDB schema: indexes, validators and backfills of
+// existing documents. Every migration has a version, they run in version order, and the
+// versions applied to a database are recorded in a collection so each runs only once.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrIrreversible = errors.New("migration cannot be reverted")
+
+// Migration is one step of the schema. Up and Down must be safe to run again: a crash
+// between applying a step and recording it means the next run applies it a second time.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error // nil when the step cannot be undone
+}
+
+// Record is what the migrations collection keeps for each applied version.
+type Record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Status reports one migration and whether it has been applied. Known is false for a
+// version recorded in the database that this build does not have, e.g. after a rollback
+// of the binary.
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+	Known       bool
+}
+
+type Migrator struct {
+	db         *mongo.Database
+	records    *mongo.Collection
+	migrations []Migration
+}
+
+// NewMigrator checks that migrations are in strictly increasing version order.
+func NewMigrator(db *mongo.Database, collectionName string, migrations []Migration) (*Migrator, error) {
+	for i, m := range migrations {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has version %d; versions start at 1", m.Description, m.Version)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d is listed after %d; keep them in increasing order", m.Version, migrations[i-1].Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up step", m.Version)
+		}
+	}
+	return &Migrator{db: db, records: db.Collection(collectionName), migrations: migrations}, nil
+}
+
+// Up applies every pending migration up to and including target, or all of them when
+// target is 0, and returns the ones it applied. It stops at the first failure.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		record := Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now().UTC()}
+		if _, err := m.records.InsertOne(ctx, record); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return done, fmt.Errorf("record migration %d: %w", migration.Version, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverts the steps most recently applied migrations, newest first, and returns the
+// ones it reverted. It refuses to touch a version this build does not know.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[int]Migration{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	slices.Reverse(versions)
+
+	done := []Migration{}
+	for _, version := range versions {
+		if len(done) == steps {
+			break
+		}
+		migration, ok := known[version]
+		if !ok {
+			return done, fmt.Errorf("migration %d is applied but unknown to this build", version)
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("migration %d (%s): %w", version, migration.Description, ErrIrreversible)
+		}
+
+		if err := migration.Down(ctx, m.db); err != nil {
+			return done, fmt.Errorf("revert migration %d (%s): %w", version, migration.Description, err)
+		}
+		if _, err := m.records.DeleteOne(ctx, bson.M{"_id": version}); err != nil {
+			return done, fmt.Errorf("unrecord migration %d: %w", version, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status lists every known migration in order, followed by any applied versions this
+// build does not know.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Description: migration.Description, Known: true}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	unknown := make([]int, 0, len(applied))
+	for version := range applied {
+		unknown = append(unknown, version)
+	}
+	slices.Sort(unknown)
+	for _, version := range unknown {
+		record := applied[version]
+		statuses = append(statuses, Status{Version: record.Version, Description: record.Description, AppliedAt: &record.AppliedAt})
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := m.records.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
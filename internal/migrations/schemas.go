@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	integer  = bson.A{"int", "long"}
+	objectID = bson.M{"bsonType": "objectId"}
+	date     = bson.M{"bsonType": "date"}
+	currency = bson.M{"bsonType": "string", "pattern": "^[A-Z]{3}$"}
+)
+
+// moneySchema matches money.Money as stored: {minor_units, currency}.
+func moneySchema(minorUnits bson.M) bson.M {
+	return bson.M{
+		"bsonType":   "object",
+		"required":   bson.A{"minor_units", "currency"},
+		"properties": bson.M{"minor_units": minorUnits, "currency": currency},
+	}
+}
+
+// schemas holds the $jsonSchema validator for each collection. They pin down the fields
+// money and access control depend on and leave the rest open, so adding a field does not
+// need a migration.
+var schemas = map[string]bson.M{
+	"users": {
+		"bsonType": "object",
+		"required": bson.A{"email", "password_hash", "full_name", "role", "kyc_status", "kyc_tier", "email_verified", "created_at"},
+		"properties": bson.M{
+			"email":          bson.M{"bsonType": "string", "pattern": "^[^@\\s]+@[^@\\s]+$"},
+			"password_hash":  bson.M{"bsonType": "string", "minLength": 1},
+			"full_name":      bson.M{"bsonType": "string"},
+			"role":           bson.M{"enum": bson.A{models.RoleUser, models.RoleSupport, models.RoleCompliance, models.RoleAdmin}},
+			"kyc_status":     bson.M{"enum": bson.A{models.KYCStatusUnverified, models.KYCStatusPending, models.KYCStatusVerified, models.KYCStatusRejected}},
+			"kyc_tier":       bson.M{"bsonType": integer, "minimum": models.KYCTierEmail, "maximum": models.KYCTierAddress},
+			"email_verified": bson.M{"bsonType": "bool"},
+			"created_at":     date,
+		},
+	},
+	"accounts": {
+		"bsonType": "object",
+		"required": bson.A{"user_id", "type", "currency", "balance", "is_active", "created_at"},
+		"properties": bson.M{
+			"user_id":    objectID,
+			"type":       bson.M{"enum": bson.A{models.AccountTypeWallet, models.AccountTypeSystem}},
+			"currency":   currency,
+			"balance":    moneySchema(bson.M{"bsonType": integer}), // system accounts may go negative
+			"is_active":  bson.M{"bsonType": "bool"},
+			"created_at": date,
+		},
+	},
+	"transactions": {
+		"bsonType": "object",
+		"required": bson.A{"type", "user_id", "from_account", "to_account", "amount", "status", "created_at"},
+		"properties": bson.M{
+			"type":         bson.M{"enum": bson.A{models.TransactionTypeTransfer, models.TransactionTypeFXConversion}},
+			"user_id":      objectID,
+			"from_account": objectID,
+			"to_account":   objectID,
+			"amount":       moneySchema(bson.M{"bsonType": integer, "minimum": 1}),
+			"status":       bson.M{"enum": bson.A{models.TransactionStatusPending, models.TransactionStatusCompleted, models.TransactionStatusFailed}},
+			"created_at":   date,
+		},
+	},
+}
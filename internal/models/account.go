@@ -3,14 +3,28 @@ package models
 import (
 	"time"
 
+	"github.com/samoray1998/fintech-wallet/internal/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	AccountTypeWallet = "wallet"
+	AccountTypeSystem = "system" // internal ledger accounts such as funding or FX pools
+)
+
 type Account struct {
 	ID        primitive.ObjectID `bson:"_id"`
 	UserID    primitive.ObjectID `bson:"user_id"`
-	Currancy  string             `bson:"currency"`
-	Balance   string             `bson:"balance"`
+	Type      string             `bson:"type"`
+	Name      string             `bson:"name,omitempty"` // only set on system accounts
+	Currency  string             `bson:"currency"`       // ISO 4217 code, one account per currency per user
+	Balance   money.Money        `bson:"balance"`        // only ever changed by the ledger
 	IsActive  bool               `bson:"is_active"`
 	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+
+	// set while staff have frozen the account
+	FrozenAt     *time.Time         `bson:"frozen_at,omitempty"`
+	FrozenBy     primitive.ObjectID `bson:"frozen_by,omitempty"`
+	FreezeReason string             `bson:"freeze_reason,omitempty"`
 }
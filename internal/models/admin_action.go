@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminAction records one back-office request and the staff member who made it.
+type AdminAction struct {
+	ID         primitive.ObjectID     `bson:"_id" json:"id"`
+	StaffID    primitive.ObjectID     `bson:"staff_id" json:"staff_id"`
+	StaffRole  string                 `bson:"staff_role" json:"staff_role"`
+	Action     string                 `bson:"action" json:"action"`
+	TargetType string                 `bson:"target_type,omitempty" json:"target_type,omitempty"`
+	TargetID   string                 `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Details    map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	Method     string                 `bson:"method" json:"method"`
+	Path       string                 `bson:"path" json:"path"`
+	Status     int                    `bson:"status" json:"status"`
+	IP         string                 `bson:"ip" json:"ip"`
+	CreatedAt  time.Time              `bson:"created_at" json:"created_at"`
+}
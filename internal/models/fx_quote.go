@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	FXQuoteStatusOpen = "open"
+	FXQuoteStatusUsed = "used"
+)
+
+// FXQuote locks a conversion rate between two of a user's accounts until ExpiresAt.
+type FXQuote struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	UserID        primitive.ObjectID `bson:"user_id"`
+	FromAccount   primitive.ObjectID `bson:"from_account"`
+	ToAccount     primitive.ObjectID `bson:"to_account"`
+	Sell          money.Money        `bson:"sell"` // debited from FromAccount
+	Buy           money.Money        `bson:"buy"`  // credited to ToAccount
+	MidRate       string             `bson:"mid_rate"`
+	Rate          string             `bson:"rate"` // mid rate less the spread
+	SpreadBps     int                `bson:"spread_bps"`
+	Status        string             `bson:"status"` // "open", "used"
+	TransactionID primitive.ObjectID `bson:"transaction_id,omitempty"`
+	ExpiresAt     time.Time          `bson:"expires_at"`
+	UsedAt        *time.Time         `bson:"used_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyRecord remembers the outcome of a mutating request so a retry can be replayed.
+type IdempotencyRecord struct {
+	ID             string    `bson:"_id"` // hash of the caller scope and the Idempotency-Key header
+	Fingerprint    string    `bson:"fingerprint"`
+	Status         string    `bson:"status"` // "processing", "completed"
+	ResponseStatus int       `bson:"response_status,omitempty"`
+	ResponseBody   []byte    `bson:"response_body,omitempty"`
+	ContentType    string    `bson:"content_type,omitempty"`
+	CreatedAt      time.Time `bson:"created_at"`
+	ExpiresAt      time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	KYCCaseStatusOpen      = "open"      // collecting documents
+	KYCCaseStatusSubmitted = "submitted" // sent to the provider, awaiting a decision
+	KYCCaseStatusVerified  = "verified"
+	KYCCaseStatusRejected  = "rejected"
+)
+
+const (
+	KYCDocumentIDFront        = "id_front"
+	KYCDocumentIDBack         = "id_back"
+	KYCDocumentSelfie         = "selfie"
+	KYCDocumentProofOfAddress = "proof_of_address"
+)
+
+// KYCCase groups the documents behind one identity verification attempt. A user has at
+// most one open case; a rejected case stays on record and the next upload opens a new one.
+type KYCCase struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	UserID       primitive.ObjectID `bson:"user_id"`
+	Status       string             `bson:"status"`
+	Documents    []KYCDocument      `bson:"documents"`
+	KYCReference string             `bson:"kyc_reference,omitempty"`
+	SubmittedAt  *time.Time         `bson:"submitted_at,omitempty"`
+	DecidedAt    *time.Time         `bson:"decided_at,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+}
+
+// KYCDocument describes an uploaded file; the encrypted bytes live in the blob store.
+type KYCDocument struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	BlobKey     string             `bson:"blob_key" json:"-"`
+	ContentType string             `bson:"content_type" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+	SHA256      string             `bson:"sha256" json:"sha256"`
+	UploadedAt  time.Time          `bson:"uploaded_at" json:"uploaded_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginAttempt counts recent failures for a normalized email address ("email:<addr>"),
+// a client address ("ip:<addr>") or a user's two-factor codes ("2fa:<id>").
+type LoginAttempt struct {
+	ID            string    `bson:"_id"`
+	Failures      int       `bson:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at"`
+	LockedUntil   time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt     time.Time `bson:"expires_at"`
+}
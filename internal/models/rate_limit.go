@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the shared token bucket state for one limiter key.
+type RateLimitBucket struct {
+	ID        string    `bson:"_id"` // policy name plus "ip:" or "user:" subject
+	Tokens    float64   `bson:"tokens"`
+	Allowed   bool      `bson:"allowed"` // whether the last take got a token
+	UpdatedAt time.Time `bson:"updated_at"`
+	ExpiresAt time.Time `bson:"expires_at"` // when the bucket would be full again, so it can be dropped
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshToken is stored only as a hash. Every rotation issues a new token in the same
+// family; presenting a token that was already rotated means it leaked.
+type RefreshToken struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	FamilyID   primitive.ObjectID `bson:"family_id"`
+	TokenHash  string             `bson:"token_hash"`
+	ReplacedBy primitive.ObjectID `bson:"replaced_by,omitempty"`
+	RotatedAt  *time.Time         `bson:"rotated_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
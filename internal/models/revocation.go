@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Revocation marks a token ("jti:<id>"), a session ("sid:<id>") or every token a user
+// was issued before RevokedAt ("user:<id>") as no longer valid.
+type Revocation struct {
+	ID        string    `bson:"_id"`
+	RevokedAt time.Time `bson:"revoked_at"`
+	ExpiresAt time.Time `bson:"expires_at"` // once every affected token has expired the record can go
+}
@@ -3,15 +3,31 @@ package models
 import (
 	"time"
 
+	"github.com/samoray1998/fintech-wallet/internal/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type Transaction struct{
-	ID primitive.ObjectID  `bson:"_id"`
-	FromAccount primitive.ObjectID `bson:"from_account`
-	ToAccount primitive.ObjectID `bson:"to_account"`
-	Amount float64 `bson:"amount"`
-	Fee float64 `bson:"fee"`
-	Status string `bson:"status"` // "pending", "completed", "failed"
-	CreatedAt time.Time `bson:"created_at"`
-}
\ No newline at end of file
+const (
+	TransactionTypeTransfer     = "transfer"
+	TransactionTypeFXConversion = "fx_conversion"
+
+	TransactionStatusPending   = "pending"
+	TransactionStatusCompleted = "completed"
+	TransactionStatusFailed    = "failed"
+)
+
+type Transaction struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Type           string             `bson:"type"`
+	UserID         primitive.ObjectID `bson:"user_id"` // the user who initiated it
+	FromAccount    primitive.ObjectID `bson:"from_account"`
+	ToAccount      primitive.ObjectID `bson:"to_account"`
+	Amount         money.Money        `bson:"amount"`
+	Fee            money.Money        `bson:"fee"`
+	Description    string             `bson:"description,omitempty"`
+	JournalEntryID primitive.ObjectID `bson:"journal_entry_id"`
+	QuoteID        primitive.ObjectID `bson:"quote_id,omitempty"`
+	LinkedID       primitive.ObjectID `bson:"linked_id,omitempty"` // the other leg of an FX conversion
+	Status         string             `bson:"status"`              // "pending", "completed", "failed"
+	CreatedAt      time.Time          `bson:"created_at"`
+}
@@ -1,17 +1,66 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	KYCStatusUnverified = "unverified"
+	KYCStatusPending    = "pending"
+	KYCStatusVerified   = "verified"
+	KYCStatusRejected   = "rejected"
+)
+
+// Roles grant access to the admin back office; customers have RoleUser.
+const (
+	RoleUser       = "user"
+	RoleSupport    = "support"    // looks up customers, unlocks logins
+	RoleCompliance = "compliance" // reviews KYC cases, freezes accounts
+	RoleAdmin      = "admin"      // everything, including granting roles
+)
+
+// KYC tiers decide which limits apply to a user.
+const (
+	KYCTierEmail   = 0 // email verified only
+	KYCTierID      = 1 // identity document verified
+	KYCTierAddress = 2 // identity and proof of address verified
+)
+
 type User struct {
-	ID        primitive.ObjectID `bson:"_id`
-	FullName  string             `bson:"full_name`
-	Password  string             `bson:"password_hash`
-	Email     string             `bson:"email`
-	KYCStatus string             `bson:"kyc_status"` // "unverified", "pending", "verified"
-	UpdatedAt time.Time          `bson:"updated_at`
-	CreatedAt time.Time          `bson:"created_at`
+	ID        primitive.ObjectID `bson:"_id"`
+	FullName  string             `bson:"full_name"`
+	Password  string             `bson:"password_hash"`
+	Email     string             `bson:"email"`
+	Role      string             `bson:"role"`       // empty on users created before roles existed, same as RoleUser
+	KYCStatus string             `bson:"kyc_status"` // "unverified", "pending", "verified", "rejected"
+
+	// identity verification with the KYC provider
+	KYCTier            int                `bson:"kyc_tier"`
+	KYCReference       string             `bson:"kyc_reference,omitempty"`
+	KYCCaseID          primitive.ObjectID `bson:"kyc_case_id,omitempty"` // case holding the documents behind KYCStatus
+	KYCSubmittedAt     *time.Time         `bson:"kyc_submitted_at,omitempty"`
+	KYCReviewedAt      *time.Time         `bson:"kyc_reviewed_at,omitempty"`
+	KYCRejectionReason string             `bson:"kyc_rejection_reason,omitempty"`
+
+	EmailVerified   bool       `bson:"email_verified"`
+	EmailVerifiedAt *time.Time `bson:"email_verified_at,omitempty"`
+
+	// two-factor authentication
+	TwoFactorEnabled  bool     `bson:"two_factor_enabled"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty"` // set during enrolment until the first code is confirmed
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty"`      // last accepted time step, so a code cannot be replayed
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty"`      // sha256 hashes of unused one-time recovery codes
+
+	UpdatedAt time.Time `bson:"updated_at"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// NormalizeEmail is the form an email address is stored and looked up in, so addresses
+// that differ only in case or surrounding space belong to the same account.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
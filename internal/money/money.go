@@ -0,0 +1,257 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("money amounts are in different currencies")
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrOverflow         = errors.New("money amount out of range")
+)
+
+// Money is an exact amount held as an integer number of minor units (cents, fils, yen...).
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New builds Money from minor units.
+func New(minorUnits int64, currency string) (Money, error) {
+	currency = NormalizeCurrency(currency)
+	if !IsSupported(currency) {
+		return Money{}, ErrUnknownCurrency
+	}
+	return Money{Amount: minorUnits, Currency: currency}, nil
+}
+
+// Zero returns a zero amount in the currency.
+func Zero(currency string) Money {
+	return Money{Currency: NormalizeCurrency(currency)}
+}
+
+// Parse reads a decimal string such as "12.345" in major units. Digits beyond the
+// currency exponent are rounded half to even.
+func Parse(amount, currency string) (Money, error) {
+	currency = NormalizeCurrency(currency)
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	amount = strings.TrimSpace(amount)
+	if amount == "" || strings.ContainsAny(amount, "/eE") {
+		return Money{}, ErrInvalidAmount
+	}
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, ErrInvalidAmount
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(exp)))
+
+	units := RoundHalfEven(r)
+	if !units.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: units.Int64(), Currency: currency}, nil
+}
+
+// MustParse is Parse for literals known to be valid; it panics otherwise.
+func MustParse(amount, currency string) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// RoundHalfEven rounds a rational to the nearest integer, ties going to the even neighbour.
+func RoundHalfEven(r *big.Rat) *big.Int {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// compare 2*|rem| against the denominator to decide which way to go
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	step := big.NewInt(int64(num.Sign()))
+
+	switch twiceRem.Cmp(den) {
+	case 1:
+		quo.Add(quo, step)
+	case 0:
+		if quo.Bit(0) == 1 {
+			quo.Add(quo, step)
+		}
+	}
+	return quo
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Rat returns the amount in major units as an exact rational.
+func (m Money) Rat() *big.Rat {
+	exp, _ := Exponent(m.Currency)
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(exp))
+}
+
+// Decimal formats the amount in major units with exactly the currency's exponent digits.
+func (m Money) Decimal() string {
+	exp, _ := Exponent(m.Currency)
+	return m.Rat().FloatString(exp)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Neg flips the sign; the most negative amount has no positive counterpart.
+func (m Money) Neg() (Money, error) {
+	if m.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: -m.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	neg, err := o.Neg()
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Add(neg)
+}
+
+// Cmp returns -1, 0 or +1 like big.Int.Cmp; both amounts must share a currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.SameCurrency(o) {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Mul scales the amount by an exact factor, rounding half to even in minor units.
+func (m Money) Mul(factor *big.Rat) (Money, error) {
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), factor)
+	units := RoundHalfEven(r)
+	if !units.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: units.Int64(), Currency: m.Currency}, nil
+}
+
+// Convert turns the amount into another currency at rate (units of target per one unit of m),
+// accounting for different exponents and rounding half to even.
+func (m Money) Convert(rate *big.Rat, currency string) (Money, error) {
+	currency = NormalizeCurrency(currency)
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	r := new(big.Rat).Mul(m.Rat(), rate)
+	r.Mul(r, new(big.Rat).SetInt(pow10(exp)))
+	units := RoundHalfEven(r)
+	if !units.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: units.Int64(), Currency: currency}, nil
+}
+
+type jsonMoney struct {
+	Value    json.RawMessage `json:"value"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON writes {"value":"12.34","currency":"USD"}; the value is a string so no
+// client ever parses money into a float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	value, err := json.Marshal(m.Decimal())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonMoney{Value: value, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts the value either as a decimal string or a bare JSON number.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw jsonMoney
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Value) == 0 {
+		return ErrInvalidAmount
+	}
+
+	value := string(raw.Value)
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(raw.Value, &value); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(value, raw.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %s %s", err, value, raw.Currency)
+	}
+	*m = parsed
+	return nil
+}
+
+type bsonMoney struct {
+	MinorUnits int64  `bson:"minor_units"`
+	Currency   string `bson:"currency"`
+}
+
+// MarshalBSON stores {minor_units: <int64>, currency: "USD"} so balances can be $inc'ed.
+func (m Money) MarshalBSON() ([]byte, error) {
+	return bson.Marshal(bsonMoney{MinorUnits: m.Amount, Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalBSON(data []byte) error {
+	var raw bsonMoney
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Amount = raw.MinorUnits
+	m.Currency = raw.Currency
+	return nil
+}
@@ -0,0 +1,199 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"12.34", "USD", 1234, nil},
+		{" 12.3 ", "usd", 1230, nil},
+		{"1.005", "USD", 100, nil}, // tie goes to the even neighbour
+		{"1.015", "USD", 102, nil},
+		{"1.0051", "USD", 101, nil}, // past the tie rounds up
+		{"-1.005", "USD", -100, nil},
+		{"-1.015", "USD", -102, nil},
+		{"1.23456789", "USD", 123, nil},
+		{"-0", "USD", 0, nil},
+		{"-0.001", "USD", 0, nil},
+		{"1234", "JPY", 1234, nil},
+		{"2.5", "JPY", 2, nil},
+		{"3.5", "JPY", 4, nil},
+		{"1.234", "KWD", 1234, nil},
+		{"0.0005", "KWD", 0, nil},
+		{"0.0015", "KWD", 2, nil},
+		{"", "USD", 0, ErrInvalidAmount},
+		{"abc", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		{"1/2", "USD", 0, ErrInvalidAmount},
+		{"1.00", "XXX", 0, ErrUnknownCurrency},
+		{"92233720368547758.08", "USD", 0, ErrOverflow},
+	}
+	for _, c := range cases {
+		got, err := Parse(c.amount, c.currency)
+		if !errors.Is(err, c.err) {
+			t.Errorf("Parse(%q, %s) error = %v, want %v", c.amount, c.currency, err, c.err)
+			continue
+		}
+		if err == nil && got.Amount != c.want {
+			t.Errorf("Parse(%q, %s) = %d minor units, want %d", c.amount, c.currency, got.Amount, c.want)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	cases := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1234, Currency: "USD"}, "12.34"},
+		{Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{Money{Amount: 1234, Currency: "JPY"}, "1234"},
+		{Money{Amount: 1234, Currency: "KWD"}, "1.234"},
+		{Money{Amount: 0, Currency: "KWD"}, "0.000"},
+	}
+	for _, c := range cases {
+		if got := c.money.Decimal(); got != c.want {
+			t.Errorf("%d %s Decimal() = %q, want %q", c.money.Amount, c.money.Currency, got, c.want)
+		}
+	}
+}
+
+func TestRoundHalfEven(t *testing.T) {
+	cases := []struct {
+		num, den int64
+		want     int64
+	}{
+		{1, 2, 0},
+		{3, 2, 2},
+		{5, 2, 2},
+		{-1, 2, 0},
+		{-3, 2, -2},
+		{-5, 2, -2},
+		{7, 3, 2},
+		{-7, 3, -2},
+		{8, 3, 3},
+	}
+	for _, c := range cases {
+		if got := RoundHalfEven(big.NewRat(c.num, c.den)); got.Int64() != c.want {
+			t.Errorf("RoundHalfEven(%d/%d) = %d, want %d", c.num, c.den, got.Int64(), c.want)
+		}
+	}
+}
+
+func TestConvertAcrossExponents(t *testing.T) {
+	cases := []struct {
+		from     Money
+		rate     *big.Rat
+		currency string
+		want     int64
+	}{
+		{MustParse("10.00", "USD"), big.NewRat(150, 1), "JPY", 1500},
+		{MustParse("1000", "JPY"), big.NewRat(1, 150), "USD", 667},
+		{MustParse("10.00", "USD"), big.NewRat(307, 1000), "KWD", 3070},
+		{MustParse("0.005", "KWD"), big.NewRat(1, 1), "USD", 0}, // half a cent, to even
+	}
+	for _, c := range cases {
+		got, err := c.from.Convert(c.rate, c.currency)
+		if err != nil {
+			t.Fatalf("Convert(%s): %v", c.from, err)
+		}
+		if got.Amount != c.want || got.Currency != c.currency {
+			t.Errorf("Convert(%s, %s) = %d %s, want %d", c.from, c.rate, got.Amount, got.Currency, c.want)
+		}
+	}
+}
+
+func TestArithmeticOverflow(t *testing.T) {
+	largest := Money{Amount: math.MaxInt64, Currency: "USD"}
+	smallest := Money{Amount: math.MinInt64, Currency: "USD"}
+
+	if _, err := largest.Add(MustParse("0.01", "USD")); !errors.Is(err, ErrOverflow) {
+		t.Errorf("max + 1 error = %v, want ErrOverflow", err)
+	}
+	if _, err := smallest.Neg(); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Neg(min) error = %v, want ErrOverflow", err)
+	}
+	if _, err := Zero("USD").Sub(smallest); !errors.Is(err, ErrOverflow) {
+		t.Errorf("0 - min error = %v, want ErrOverflow", err)
+	}
+	if _, err := largest.Add(MustParse("1.00", "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, m := range []Money{
+		MustParse("12.34", "USD"),
+		MustParse("-0.01", "EUR"),
+		MustParse("1234", "JPY"),
+		MustParse("1.234", "KWD"),
+	} {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Marshal(%s): %v", m, err)
+		}
+		var back Money
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if back != m {
+			t.Errorf("%s came back as %s via %s", m, back, data)
+		}
+	}
+
+	// clients may send the value as a bare number
+	var m Money
+	if err := json.Unmarshal([]byte(`{"value": 12.5, "currency": "usd"}`), &m); err != nil {
+		t.Fatalf("Unmarshal bare number: %v", err)
+	}
+	if m != (Money{Amount: 1250, Currency: "USD"}) {
+		t.Errorf("bare number parsed as %s", m)
+	}
+	if err := json.Unmarshal([]byte(`{"currency": "USD"}`), &m); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("missing value error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestBSONRoundTrip(t *testing.T) {
+	type doc struct {
+		Balance Money `bson:"balance"`
+	}
+	for _, m := range []Money{
+		MustParse("12.34", "USD"),
+		MustParse("1234", "JPY"),
+		MustParse("-1.234", "KWD"),
+	} {
+		data, err := bson.Marshal(doc{Balance: m})
+		if err != nil {
+			t.Fatalf("Marshal(%s): %v", m, err)
+		}
+		var raw bson.M
+		if err := bson.Unmarshal(data, &raw); err != nil {
+			t.Fatal(err)
+		}
+		stored := raw["balance"].(bson.M)
+		if stored["minor_units"] != m.Amount || stored["currency"] != m.Currency {
+			t.Errorf("%s stored as %v, want minor_units and currency", m, stored)
+		}
+
+		var back doc
+		if err := bson.Unmarshal(data, &back); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", m, err)
+		}
+		if back.Balance != m {
+			t.Errorf("%s came back as %s", m, back.Balance)
+		}
+	}
+}
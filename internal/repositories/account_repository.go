@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountExists   = errors.New("account already exists for this currency")
+)
+
+// AccountRepository stores wallet accounts. Balances only change through the ledger.
+type AccountRepository interface {
+	CreateAccount(ctx context.Context, account *models.Account) (*models.Account, error)
+	FindByID(ctx context.Context, id string) (*models.Account, error)
+	FindByUserAndCurrency(ctx context.Context, userID primitive.ObjectID, currency string) (*models.Account, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Account, error)
+	SetFrozen(ctx context.Context, id primitive.ObjectID, frozen bool, staffID primitive.ObjectID, reason string) (*models.Account, error)
+}
+
+type MongoAccountRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAccountRepo(db *mongo.Database, collectionName string) *MongoAccountRepository {
+	return &MongoAccountRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// / CreateAccount inserts a new account, refusing a second account in the same currency
+func (r *MongoAccountRepository) CreateAccount(ctx context.Context, account *models.Account) (*models.Account, error) {
+	existing, err := r.FindByUserAndCurrency(ctx, account.UserID, account.Currency)
+	if err != nil && !errors.Is(err, ErrAccountNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAccountExists
+	}
+
+	account.ID = primitive.NewObjectID()
+	account.CreatedAt = time.Now()
+	account.UpdatedAt = account.CreatedAt
+
+	_, err = r.collection.InsertOne(ctx, account)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAccountExists
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (r *MongoAccountRepository) FindByID(ctx context.Context, id string) (*models.Account, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	var account models.Account
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&account)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *MongoAccountRepository) FindByUserAndCurrency(ctx context.Context, userID primitive.ObjectID, currency string) (*models.Account, error) {
+	var account models.Account
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "type": models.AccountTypeWallet, "currency": currency}).Decode(&account)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListByUser returns every account owned by the user, oldest first
+func (r *MongoAccountRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Account, error) {
+	accounts := []models.Account{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID, "type": models.AccountTypeWallet}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// SetFrozen freezes or unfreezes a wallet account; frozen accounts can neither send nor receive
+func (r *MongoAccountRepository) SetFrozen(ctx context.Context, id primitive.ObjectID, frozen bool, staffID primitive.ObjectID, reason string) (*models.Account, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set":   bson.M{"is_active": true, "updated_at": now},
+		"$unset": bson.M{"frozen_at": "", "frozen_by": "", "freeze_reason": ""},
+	}
+	if frozen {
+		update = bson.M{"$set": bson.M{
+			"is_active":     false,
+			"frozen_at":     now,
+			"frozen_by":     staffID,
+			"freeze_reason": reason,
+			"updated_at":    now,
+		}}
+	}
+
+	var account models.Account
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "type": models.AccountTypeWallet},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&account)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AdminActionRepository interface {
+	Create(ctx context.Context, action *models.AdminAction) error
+	List(ctx context.Context, staffID *primitive.ObjectID, page int, limit int) ([]models.AdminAction, error)
+}
+
+type MongoAdminActionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAdminActionRepo(db *mongo.Database, collectionName string) *MongoAdminActionRepository {
+	return &MongoAdminActionRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoAdminActionRepository) Create(ctx context.Context, action *models.AdminAction) error {
+	action.ID = primitive.NewObjectID()
+	action.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, action)
+	return err
+}
+
+// List returns recorded actions, newest first, optionally only those by one staff member
+func (r *MongoAdminActionRepository) List(ctx context.Context, staffID *primitive.ObjectID, page int, limit int) ([]models.AdminAction, error) {
+	actions := []models.AdminAction{}
+
+	filter := bson.M{}
+	if staffID != nil {
+		filter["staff_id"] = *staffID
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &actions); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrQuoteNotFound = errors.New("quote not found")
+	ErrQuoteUsed     = errors.New("quote has already been used")
+	ErrQuoteExpired  = errors.New("quote has expired")
+)
+
+type FXQuoteRepository interface {
+	Create(ctx context.Context, quote *models.FXQuote) (*models.FXQuote, error)
+	FindByID(ctx context.Context, id string) (*models.FXQuote, error)
+	MarkUsed(ctx context.Context, quote *models.FXQuote, transactionID primitive.ObjectID, now time.Time) error
+}
+
+type MongoFXQuoteRepository struct {
+	collection *mongo.Collection
+}
+
+func NewFXQuoteRepo(db *mongo.Database, collectionName string) *MongoFXQuoteRepository {
+	return &MongoFXQuoteRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoFXQuoteRepository) Create(ctx context.Context, quote *models.FXQuote) (*models.FXQuote, error) {
+	quote.ID = primitive.NewObjectID()
+	quote.Status = models.FXQuoteStatusOpen
+	quote.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, quote)
+	if err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+func (r *MongoFXQuoteRepository) FindByID(ctx context.Context, id string) (*models.FXQuote, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrQuoteNotFound
+	}
+
+	var quote models.FXQuote
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&quote)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// MarkUsed atomically consumes an open, unexpired quote so it can only ever be executed once
+func (r *MongoFXQuoteRepository) MarkUsed(ctx context.Context, quote *models.FXQuote, transactionID primitive.ObjectID, now time.Time) error {
+	filter := bson.M{
+		"_id":        quote.ID,
+		"status":     models.FXQuoteStatusOpen,
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":         models.FXQuoteStatusUsed,
+		"transaction_id": transactionID,
+		"used_at":        now,
+	}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if quote.Status == models.FXQuoteStatusUsed {
+			return ErrQuoteUsed
+		}
+		if !quote.ExpiresAt.After(now) {
+			return ErrQuoteExpired
+		}
+		return ErrQuoteUsed
+	}
+
+	quote.Status = models.FXQuoteStatusUsed
+	quote.TransactionID = transactionID
+	quote.UsedAt = &now
+	return nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, id string, status int, contentType string, body []byte) error
+	Release(ctx context.Context, id string) error
+}
+
+// MongoIdempotencyRepository stamps each record with its expiry; a TTL index on
+// expires_at removes it.
+type MongoIdempotencyRepository struct {
+	collection *mongo.Collection
+	ttl        time.Duration
+}
+
+func NewIdempotencyRepo(db *mongo.Database, collectionName string, ttl time.Duration) *MongoIdempotencyRepository {
+	return &MongoIdempotencyRepository{
+		collection: db.Collection(collectionName),
+		ttl:        ttl,
+	}
+}
+
+// Reserve claims the key for a new request. When the key is already taken the stored
+// record is returned instead and nothing is written.
+func (r *MongoIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	record.Status = models.IdempotencyStatusProcessing
+	record.CreatedAt = time.Now()
+	record.ExpiresAt = record.CreatedAt.Add(r.ttl)
+
+	_, err := r.collection.InsertOne(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	var existing models.IdempotencyRecord
+	if err := r.collection.FindOne(ctx, bson.M{"_id": record.ID}).Decode(&existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (r *MongoIdempotencyRepository) Complete(ctx context.Context, id string, status int, contentType string, body []byte) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"status":          models.IdempotencyStatusCompleted,
+		"response_status": status,
+		"content_type":    contentType,
+		"response_body":   body,
+	}})
+	return err
+}
+
+// Release forgets a reservation so the client may retry, e.g. after a server error
+func (r *MongoIdempotencyRepository) Release(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrKYCCaseNotFound = errors.New("kyc case not found")
+
+// KYCCaseRepository stores KYC cases; a user has at most one open case at a time.
+type KYCCaseRepository interface {
+	OpenCase(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error)
+	FindOpenCase(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error)
+	FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error)
+	FindByID(ctx context.Context, id string) (*models.KYCCase, error)
+	List(ctx context.Context, status string, page int, limit int) ([]models.KYCCase, error)
+	FindByReference(ctx context.Context, reference string) (*models.KYCCase, error)
+	ReplaceDocument(ctx context.Context, caseID primitive.ObjectID, doc models.KYCDocument) (*models.KYCDocument, error)
+	MarkSubmitted(ctx context.Context, caseID primitive.ObjectID, reference string) error
+	MarkDecided(ctx context.Context, reference string, status string) error
+}
+
+type MongoKYCCaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewKYCCaseRepo(db *mongo.Database, collectionName string) *MongoKYCCaseRepository {
+	return &MongoKYCCaseRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// OpenCase returns the user's open case, creating it when there is none
+func (r *MongoKYCCaseRepository) OpenCase(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error) {
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	update := bson.M{"$setOnInsert": bson.M{
+		"_id":        primitive.NewObjectID(),
+		"documents":  bson.A{},
+		"created_at": now,
+		"updated_at": now,
+	}}
+	filter := bson.M{"user_id": userID, "status": models.KYCCaseStatusOpen}
+
+	var kycCase models.KYCCase
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&kycCase)
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.collection.FindOne(ctx, filter).Decode(&kycCase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &kycCase, nil
+}
+
+// FindOpenCase returns the user's open case without creating one
+func (r *MongoKYCCaseRepository) FindOpenCase(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error) {
+	var kycCase models.KYCCase
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "status": models.KYCCaseStatusOpen}).Decode(&kycCase)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKYCCaseNotFound
+		}
+		return nil, err
+	}
+	return &kycCase, nil
+}
+
+// FindLatest returns the user's most recent case in any status
+func (r *MongoKYCCaseRepository) FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error) {
+	var kycCase models.KYCCase
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&kycCase)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKYCCaseNotFound
+		}
+		return nil, err
+	}
+	return &kycCase, nil
+}
+
+func (r *MongoKYCCaseRepository) FindByID(ctx context.Context, id string) (*models.KYCCase, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrKYCCaseNotFound
+	}
+
+	var kycCase models.KYCCase
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&kycCase)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKYCCaseNotFound
+		}
+		return nil, err
+	}
+	return &kycCase, nil
+}
+
+// List returns cases in status (any status when empty), oldest first so reviewers work
+// through the queue in order
+func (r *MongoKYCCaseRepository) List(ctx context.Context, status string, page int, limit int) ([]models.KYCCase, error) {
+	cases := []models.KYCCase{}
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &cases); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func (r *MongoKYCCaseRepository) FindByReference(ctx context.Context, reference string) (*models.KYCCase, error) {
+	var kycCase models.KYCCase
+	err := r.collection.FindOne(ctx, bson.M{"kyc_reference": reference}).Decode(&kycCase)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKYCCaseNotFound
+		}
+		return nil, err
+	}
+	return &kycCase, nil
+}
+
+// ReplaceDocument stores doc on an open case in place of any earlier document of the same
+// type, returning the replaced document so its blob can be removed
+func (r *MongoKYCCaseRepository) ReplaceDocument(ctx context.Context, caseID primitive.ObjectID, doc models.KYCDocument) (*models.KYCDocument, error) {
+	filter := bson.M{"_id": caseID, "status": models.KYCCaseStatusOpen}
+
+	var before models.KYCCase
+	err := r.collection.FindOneAndUpdate(ctx, filter,
+		bson.M{"$pull": bson.M{"documents": bson.M{"type": doc.Type}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKYCCaseNotFound
+		}
+		return nil, err
+	}
+
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$push": bson.M{"documents": doc},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, ErrKYCCaseNotFound
+	}
+
+	for _, existing := range before.Documents {
+		if existing.Type == doc.Type {
+			return &existing, nil
+		}
+	}
+	return nil, nil
+}
+
+// MarkSubmitted closes an open case for uploads once it has been sent to the provider
+func (r *MongoKYCCaseRepository) MarkSubmitted(ctx context.Context, caseID primitive.ObjectID, reference string) error {
+	now := time.Now()
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": caseID, "status": models.KYCCaseStatusOpen},
+		bson.M{"$set": bson.M{
+			"status":        models.KYCCaseStatusSubmitted,
+			"kyc_reference": reference,
+			"submitted_at":  now,
+			"updated_at":    now,
+		}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrKYCCaseNotFound
+	}
+	return nil
+}
+
+// MarkDecided records the provider's decision on the submitted case with reference
+func (r *MongoKYCCaseRepository) MarkDecided(ctx context.Context, reference string, status string) error {
+	now := time.Now()
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"kyc_reference": reference, "status": models.KYCCaseStatusSubmitted},
+		bson.M{"$set": bson.M{"status": status, "decided_at": now, "updated_at": now}})
+	return err
+}
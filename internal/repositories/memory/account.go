@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AccountRepository struct {
+	clock    clock.Clock
+	mu       sync.RWMutex
+	accounts map[primitive.ObjectID]*models.Account
+}
+
+func NewAccountRepo(clk clock.Clock) *AccountRepository {
+	return &AccountRepository{clock: clk, accounts: map[primitive.ObjectID]*models.Account{}}
+}
+
+var _ repositories.AccountRepository = (*AccountRepository)(nil)
+
+func copyAccount(a *models.Account) *models.Account {
+	c := *a
+	return &c
+}
+
+// CreateAccount enforces one wallet per user and currency, like the unique index does.
+func (r *AccountRepository) CreateAccount(ctx context.Context, account *models.Account) (*models.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account.Type == models.AccountTypeWallet {
+		for _, existing := range r.accounts {
+			if existing.Type == models.AccountTypeWallet && existing.UserID == account.UserID && existing.Currency == account.Currency {
+				return nil, repositories.ErrAccountExists
+			}
+		}
+	}
+
+	account.ID = primitive.NewObjectID()
+	account.CreatedAt = r.clock.Now()
+	account.UpdatedAt = account.CreatedAt
+	r.accounts[account.ID] = copyAccount(account)
+	return account, nil
+}
+
+func (r *AccountRepository) FindByID(ctx context.Context, id string) (*models.Account, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, repositories.ErrAccountNotFound
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if account, ok := r.accounts[objectID]; ok {
+		return copyAccount(account), nil
+	}
+	return nil, repositories.ErrAccountNotFound
+}
+
+func (r *AccountRepository) FindByUserAndCurrency(ctx context.Context, userID primitive.ObjectID, currency string) (*models.Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, account := range r.accounts {
+		if account.Type == models.AccountTypeWallet && account.UserID == userID && account.Currency == currency {
+			return copyAccount(account), nil
+		}
+	}
+	return nil, repositories.ErrAccountNotFound
+}
+
+func (r *AccountRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]models.Account, error) {
+	r.mu.RLock()
+	accounts := []models.Account{}
+	for _, account := range r.accounts {
+		if account.Type == models.AccountTypeWallet && account.UserID == userID {
+			accounts = append(accounts, *account)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].CreatedAt.Before(accounts[j].CreatedAt) })
+	return accounts, nil
+}
+
+func (r *AccountRepository) SetFrozen(ctx context.Context, id primitive.ObjectID, frozen bool, staffID primitive.ObjectID, reason string) (*models.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok || account.Type != models.AccountTypeWallet {
+		return nil, repositories.ErrAccountNotFound
+	}
+
+	now := r.clock.Now()
+	account.UpdatedAt = now
+	if frozen {
+		account.IsActive = false
+		account.FrozenAt = &now
+		account.FrozenBy = staffID
+		account.FreezeReason = reason
+	} else {
+		account.IsActive = true
+		account.FrozenAt = nil
+		account.FrozenBy = primitive.NilObjectID
+		account.FreezeReason = ""
+	}
+	return copyAccount(account), nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminActionRepository struct {
+	clock   clock.Clock
+	mu      sync.Mutex
+	actions []models.AdminAction
+}
+
+func NewAdminActionRepo(clk clock.Clock) *AdminActionRepository {
+	return &AdminActionRepository{clock: clk}
+}
+
+var _ repositories.AdminActionRepository = (*AdminActionRepository)(nil)
+
+func (r *AdminActionRepository) Create(ctx context.Context, action *models.AdminAction) error {
+	action.ID = primitive.NewObjectID()
+	action.CreatedAt = r.clock.Now()
+
+	stored := *action
+	stored.Details = maps.Clone(action.Details)
+	r.mu.Lock()
+	r.actions = append(r.actions, stored)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *AdminActionRepository) List(ctx context.Context, staffID *primitive.ObjectID, page int, limit int) ([]models.AdminAction, error) {
+	r.mu.Lock()
+	actions := []models.AdminAction{}
+	for _, action := range r.actions {
+		if staffID == nil || action.StaffID == *staffID {
+			actions = append(actions, action)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].CreatedAt.After(actions[j].CreatedAt) })
+	return paginate(actions, page, limit), nil
+}
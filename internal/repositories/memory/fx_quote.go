@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FXQuoteRepository struct {
+	clock  clock.Clock
+	mu     sync.Mutex
+	quotes map[primitive.ObjectID]models.FXQuote
+}
+
+func NewFXQuoteRepo(clk clock.Clock) *FXQuoteRepository {
+	return &FXQuoteRepository{clock: clk, quotes: map[primitive.ObjectID]models.FXQuote{}}
+}
+
+var _ repositories.FXQuoteRepository = (*FXQuoteRepository)(nil)
+
+func (r *FXQuoteRepository) Create(ctx context.Context, quote *models.FXQuote) (*models.FXQuote, error) {
+	quote.ID = primitive.NewObjectID()
+	quote.Status = models.FXQuoteStatusOpen
+	quote.CreatedAt = r.clock.Now()
+
+	r.mu.Lock()
+	r.quotes[quote.ID] = *quote
+	r.mu.Unlock()
+	return quote, nil
+}
+
+func (r *FXQuoteRepository) FindByID(ctx context.Context, id string) (*models.FXQuote, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, repositories.ErrQuoteNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if quote, ok := r.quotes[objectID]; ok {
+		return &quote, nil
+	}
+	return nil, repositories.ErrQuoteNotFound
+}
+
+func (r *FXQuoteRepository) MarkUsed(ctx context.Context, quote *models.FXQuote, transactionID primitive.ObjectID, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.quotes[quote.ID]
+	if !ok || stored.Status != models.FXQuoteStatusOpen {
+		return repositories.ErrQuoteUsed
+	}
+	if !stored.ExpiresAt.After(now) {
+		return repositories.ErrQuoteExpired
+	}
+
+	stored.Status = models.FXQuoteStatusUsed
+	stored.TransactionID = transactionID
+	stored.UsedAt = &now
+	r.quotes[quote.ID] = stored
+
+	quote.Status = stored.Status
+	quote.TransactionID = transactionID
+	quote.UsedAt = &now
+	return nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+// IdempotencyRepository keeps records until they are released; there is no TTL.
+type IdempotencyRepository struct {
+	clock   clock.Clock
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func NewIdempotencyRepo(clk clock.Clock) *IdempotencyRepository {
+	return &IdempotencyRepository{clock: clk, records: map[string]models.IdempotencyRecord{}}
+}
+
+var _ repositories.IdempotencyRepository = (*IdempotencyRepository)(nil)
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[record.ID]; ok {
+		existing.ResponseBody = slices.Clone(existing.ResponseBody)
+		return &existing, nil
+	}
+
+	record.Status = models.IdempotencyStatusProcessing
+	record.CreatedAt = r.clock.Now()
+	r.records[record.ID] = *record
+	return nil, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, id string, status int, contentType string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[id]
+	if !ok {
+		return nil
+	}
+	record.Status = models.IdempotencyStatusCompleted
+	record.ResponseStatus = status
+	record.ContentType = contentType
+	record.ResponseBody = slices.Clone(body)
+	r.records[id] = record
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, id string) error {
+	r.mu.Lock()
+	delete(r.records, id)
+	r.mu.Unlock()
+	return nil
+}
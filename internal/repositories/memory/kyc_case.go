@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type KYCCaseRepository struct {
+	clock clock.Clock
+	mu    sync.Mutex
+	cases map[primitive.ObjectID]*models.KYCCase
+}
+
+func NewKYCCaseRepo(clk clock.Clock) *KYCCaseRepository {
+	return &KYCCaseRepository{clock: clk, cases: map[primitive.ObjectID]*models.KYCCase{}}
+}
+
+var _ repositories.KYCCaseRepository = (*KYCCaseRepository)(nil)
+
+func copyKYCCase(c *models.KYCCase) *models.KYCCase {
+	copied := *c
+	copied.Documents = slices.Clone(c.Documents)
+	return &copied
+}
+
+// OpenCase returns the user's open case, creating it when there is none. At most one
+// case per user is open at a time, as the partial unique index ensures.
+func (r *KYCCaseRepository) OpenCase(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if kycCase := r.find(func(c *models.KYCCase) bool { return c.UserID == userID && c.Status == models.KYCCaseStatusOpen }); kycCase != nil {
+		return copyKYCCase(kycCase), nil
+	}
+
+	now := r.clock.Now()
+	kycCase := &models.KYCCase{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Status:    models.KYCCaseStatusOpen,
+		Documents: []models.KYCDocument{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.cases[kycCase.ID] = kycCase
+	return copyKYCCase(kycCase), nil
+}
+
+func (r *KYCCaseRepository) FindOpenCase(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error) {
+	return r.findOne(func(c *models.KYCCase) bool { return c.UserID == userID && c.Status == models.KYCCaseStatusOpen })
+}
+
+func (r *KYCCaseRepository) FindLatest(ctx context.Context, userID primitive.ObjectID) (*models.KYCCase, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *models.KYCCase
+	for _, kycCase := range r.cases {
+		if kycCase.UserID == userID && (latest == nil || kycCase.CreatedAt.After(latest.CreatedAt)) {
+			latest = kycCase
+		}
+	}
+	if latest == nil {
+		return nil, repositories.ErrKYCCaseNotFound
+	}
+	return copyKYCCase(latest), nil
+}
+
+func (r *KYCCaseRepository) FindByID(ctx context.Context, id string) (*models.KYCCase, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, repositories.ErrKYCCaseNotFound
+	}
+	return r.findOne(func(c *models.KYCCase) bool { return c.ID == objectID })
+}
+
+func (r *KYCCaseRepository) List(ctx context.Context, status string, page int, limit int) ([]models.KYCCase, error) {
+	r.mu.Lock()
+	cases := []models.KYCCase{}
+	for _, kycCase := range r.cases {
+		if status == "" || kycCase.Status == status {
+			cases = append(cases, *copyKYCCase(kycCase))
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(cases, func(i, j int) bool { return cases[i].UpdatedAt.Before(cases[j].UpdatedAt) })
+	return paginate(cases, page, limit), nil
+}
+
+func (r *KYCCaseRepository) FindByReference(ctx context.Context, reference string) (*models.KYCCase, error) {
+	return r.findOne(func(c *models.KYCCase) bool { return c.KYCReference == reference })
+}
+
+func (r *KYCCaseRepository) ReplaceDocument(ctx context.Context, caseID primitive.ObjectID, doc models.KYCDocument) (*models.KYCDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kycCase, ok := r.cases[caseID]
+	if !ok || kycCase.Status != models.KYCCaseStatusOpen {
+		return nil, repositories.ErrKYCCaseNotFound
+	}
+
+	var replaced *models.KYCDocument
+	documents := []models.KYCDocument{}
+	for _, existing := range kycCase.Documents {
+		if existing.Type == doc.Type {
+			replaced = &existing
+			continue
+		}
+		documents = append(documents, existing)
+	}
+	kycCase.Documents = append(documents, doc)
+	kycCase.UpdatedAt = r.clock.Now()
+	return replaced, nil
+}
+
+func (r *KYCCaseRepository) MarkSubmitted(ctx context.Context, caseID primitive.ObjectID, reference string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kycCase, ok := r.cases[caseID]
+	if !ok || kycCase.Status != models.KYCCaseStatusOpen {
+		return repositories.ErrKYCCaseNotFound
+	}
+	now := r.clock.Now()
+	kycCase.Status = models.KYCCaseStatusSubmitted
+	kycCase.KYCReference = reference
+	kycCase.SubmittedAt = &now
+	kycCase.UpdatedAt = now
+	return nil
+}
+
+func (r *KYCCaseRepository) MarkDecided(ctx context.Context, reference string, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kycCase := r.find(func(c *models.KYCCase) bool {
+		return c.KYCReference == reference && c.Status == models.KYCCaseStatusSubmitted
+	})
+	if kycCase == nil {
+		return nil
+	}
+	now := r.clock.Now()
+	kycCase.Status = status
+	kycCase.DecidedAt = &now
+	kycCase.UpdatedAt = now
+	return nil
+}
+
+func (r *KYCCaseRepository) findOne(match func(c *models.KYCCase) bool) (*models.KYCCase, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if kycCase := r.find(match); kycCase != nil {
+		return copyKYCCase(kycCase), nil
+	}
+	return nil, repositories.ErrKYCCaseNotFound
+}
+
+// find returns the stored case matching match; the caller must hold the lock.
+func (r *KYCCaseRepository) find(match func(c *models.KYCCase) bool) *models.KYCCase {
+	for _, kycCase := range r.cases {
+		if match(kycCase) {
+			return kycCase
+		}
+	}
+	return nil
+}
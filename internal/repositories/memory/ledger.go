@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ledger keeps journal entries in memory and applies them to the balances held by an
+// AccountRepository, the way the Mongo ledger shares the accounts collection.
+type Ledger struct {
+	clock    clock.Clock
+	accounts *AccountRepository
+	mu       sync.Mutex
+	entries  []ledger.JournalEntry
+}
+
+func NewLedger(clk clock.Clock, accounts *AccountRepository) *Ledger {
+	return &Ledger{clock: clk, accounts: accounts}
+}
+
+var _ ledger.Ledger = (*Ledger)(nil)
+
+// Post checks every posting before applying any, so a rejected entry leaves all balances
+// untouched.
+func (l *Ledger) Post(ctx context.Context, entry *ledger.JournalEntry) (*ledger.JournalEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	l.accounts.mu.Lock()
+	defer l.accounts.mu.Unlock()
+
+	balances := map[primitive.ObjectID]money.Money{}
+	for _, p := range entry.Postings {
+		account, ok := l.accounts.accounts[p.AccountID]
+		if !ok {
+			return nil, ledger.ErrUnknownAccount
+		}
+		if account.Currency != p.Amount.Currency {
+			return nil, ledger.ErrCurrencyMismatch
+		}
+
+		balance, seen := balances[p.AccountID]
+		if !seen {
+			balance = account.Balance
+		}
+		balance, err := balance.Add(p.Amount)
+		if err != nil {
+			return nil, err
+		}
+		// wallet accounts can never be overdrawn; system accounts may go negative
+		if balance.IsNegative() && account.Type != models.AccountTypeSystem {
+			return nil, ledger.ErrInsufficientFunds
+		}
+		balances[p.AccountID] = balance
+	}
+
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = l.clock.Now()
+	for id, balance := range balances {
+		account := l.accounts.accounts[id]
+		account.Balance = balance
+		account.UpdatedAt = entry.CreatedAt
+	}
+
+	stored := *entry
+	stored.Postings = slices.Clone(entry.Postings)
+	l.mu.Lock()
+	l.entries = append(l.entries, stored)
+	l.mu.Unlock()
+	return entry, nil
+}
+
+func (l *Ledger) SystemAccount(ctx context.Context, name, currency string) (*models.Account, error) {
+	l.accounts.mu.Lock()
+	defer l.accounts.mu.Unlock()
+
+	for _, account := range l.accounts.accounts {
+		if account.Type == models.AccountTypeSystem && account.Name == name && account.Currency == currency {
+			return copyAccount(account), nil
+		}
+	}
+
+	now := l.clock.Now()
+	account := &models.Account{
+		ID:        primitive.NewObjectID(),
+		Type:      models.AccountTypeSystem,
+		Name:      name,
+		Currency:  currency,
+		Balance:   money.Zero(currency),
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	l.accounts.accounts[account.ID] = account
+	return copyAccount(account), nil
+}
+
+func (l *Ledger) BalanceAt(ctx context.Context, accountID primitive.ObjectID, at time.Time) (money.Money, error) {
+	account, err := l.account(accountID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return l.sumPostings(account, at), nil
+}
+
+// Reconcile holds the accounts lock across both reads, as Post does across its writes, so
+// it never sees a balance without the entry that produced it.
+func (l *Ledger) Reconcile(ctx context.Context, accountID primitive.ObjectID) (*ledger.Reconciliation, error) {
+	l.accounts.mu.RLock()
+	defer l.accounts.mu.RUnlock()
+	stored, ok := l.accounts.accounts[accountID]
+	if !ok {
+		return nil, ledger.ErrUnknownAccount
+	}
+	account := copyAccount(stored)
+
+	asOf := l.clock.Now()
+	return &ledger.Reconciliation{
+		AccountID:      accountID,
+		StoredBalance:  account.Balance,
+		DerivedBalance: l.sumPostings(account, asOf),
+		AsOf:           asOf,
+	}, nil
+}
+
+func (l *Ledger) Entries(ctx context.Context, accountID primitive.ObjectID, limit int64) ([]ledger.JournalEntry, error) {
+	l.mu.Lock()
+	entries := []ledger.JournalEntry{}
+	for _, entry := range l.entries {
+		if touches(entry, accountID) {
+			entries = append(entries, entry)
+		}
+	}
+	l.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	if limit > 0 && int64(len(entries)) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (l *Ledger) account(accountID primitive.ObjectID) (*models.Account, error) {
+	l.accounts.mu.RLock()
+	defer l.accounts.mu.RUnlock()
+	account, ok := l.accounts.accounts[accountID]
+	if !ok {
+		return nil, ledger.ErrUnknownAccount
+	}
+	return copyAccount(account), nil
+}
+
+func (l *Ledger) sumPostings(account *models.Account, at time.Time) money.Money {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	balance := money.Zero(account.Currency)
+	for _, entry := range l.entries {
+		if entry.CreatedAt.After(at) {
+			continue
+		}
+		for _, p := range entry.Postings {
+			if p.AccountID == account.ID {
+				balance.Amount += p.Amount.Amount
+			}
+		}
+	}
+	return balance
+}
+
+func touches(entry ledger.JournalEntry, accountID primitive.ObjectID) bool {
+	for _, p := range entry.Postings {
+		if p.AccountID == accountID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+type LoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewLoginAttemptRepo() *LoginAttemptRepository {
+	return &LoginAttemptRepository{attempts: map[string]models.LoginAttempt{}}
+}
+
+var _ repositories.LoginAttemptRepository = (*LoginAttemptRepository)(nil)
+
+func (r *LoginAttemptRepository) Find(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if attempt, ok := r.attempts[key]; ok {
+		return &attempt, nil
+	}
+	return nil, nil
+}
+
+// RecordFailure restarts the count when the previous failure is older than window, as
+// the Mongo pipeline does.
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt := r.attempts[key]
+	attempt.ID = key
+	if attempt.LastFailureAt.After(now.Add(-window)) {
+		attempt.Failures++
+	} else {
+		attempt.Failures = 1
+	}
+	attempt.LastFailureAt = now
+	attempt.ExpiresAt = now.Add(window)
+	if attempt.LockedUntil.After(attempt.ExpiresAt) {
+		attempt.ExpiresAt = attempt.LockedUntil
+	}
+	r.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempt.LockedUntil = until
+	if until.After(attempt.ExpiresAt) {
+		attempt.ExpiresAt = until
+	}
+	r.attempts[key] = attempt
+	return nil
+}
+
+func (r *LoginAttemptRepository) Clear(ctx context.Context, key string) error {
+	r.mu.Lock()
+	delete(r.attempts, key)
+	r.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMarkRotatedHasOneWinner(t *testing.T) {
+	repo := NewTokenRepo(clock.System)
+	ctx := context.Background()
+	token, err := repo.Create(ctx, &models.RefreshToken{TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, 20)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- repo.MarkRotated(ctx, token.ID, primitive.NewObjectID(), time.Now())
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	won := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			won++
+		case !errors.Is(err, repositories.ErrTokenAlreadyRotated):
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("%d callers rotated the token, want 1", won)
+	}
+}
+
+func TestRecordsAreCopied(t *testing.T) {
+	repo := NewUserRepo(clock.System)
+	user, err := repo.CreateUser(&models.User{Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	user.Email = "changed@example.com"
+
+	stored, err := repo.FindByID(user.ID.Hex())
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if stored.Email != "ada@example.com" {
+		t.Fatalf("caller's change leaked into the store: %q", stored.Email)
+	}
+}
+
+func TestCreateUserRejectsDuplicateEmail(t *testing.T) {
+	repo := NewUserRepo(clock.System)
+	if _, err := repo.CreateUser(&models.User{Email: "ada@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := repo.CreateUser(&models.User{Email: "ada@example.com"}); !errors.Is(err, repositories.ErrEmailTaken) {
+		t.Fatalf("second CreateUser: got %v, want ErrEmailTaken", err)
+	}
+}
+
+func newTestLedger(t *testing.T) (*Ledger, *AccountRepository) {
+	t.Helper()
+	accounts := NewAccountRepo(clock.System)
+	return NewLedger(clock.System, accounts), accounts
+}
+
+func openWallet(t *testing.T, accounts *AccountRepository, currency string) *models.Account {
+	t.Helper()
+	account, err := accounts.CreateAccount(context.Background(), &models.Account{
+		UserID:   primitive.NewObjectID(),
+		Type:     models.AccountTypeWallet,
+		Currency: currency,
+		Balance:  money.Zero(currency),
+		IsActive: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	return account
+}
+
+func post(ctx context.Context, l *Ledger, from, to primitive.ObjectID, amount string) error {
+	postings, err := ledger.Transfer(from, to, money.MustParse(amount, "USD"))
+	if err != nil {
+		return err
+	}
+	_, err = l.Post(ctx, &ledger.JournalEntry{Reference: "test", Postings: postings})
+	return err
+}
+
+func TestSystemAccountsMayGoNegativeButWalletsMayNot(t *testing.T) {
+	l, accounts := newTestLedger(t)
+	ctx := context.Background()
+	funding, err := l.SystemAccount(ctx, "funding", "USD")
+	if err != nil {
+		t.Fatalf("SystemAccount: %v", err)
+	}
+	if again, _ := l.SystemAccount(ctx, "funding", "USD"); again.ID != funding.ID {
+		t.Fatal("SystemAccount created a second account for the same name and currency")
+	}
+	alice := openWallet(t, accounts, "USD")
+	bob := openWallet(t, accounts, "USD")
+
+	if err := post(ctx, l, funding.ID, alice.ID, "50.00"); err != nil {
+		t.Fatalf("funding a wallet from a system account: %v", err)
+	}
+	if err := post(ctx, l, alice.ID, bob.ID, "50.01"); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("overdrawing a wallet: got %v, want ErrInsufficientFunds", err)
+	}
+
+	balances := map[primitive.ObjectID]string{funding.ID: "-50.00", alice.ID: "50.00", bob.ID: "0.00"}
+	for id, want := range balances {
+		account, _ := accounts.FindByID(ctx, id.Hex())
+		if got := account.Balance.Decimal(); got != want {
+			t.Errorf("balance of %s = %s, want %s; a rejected entry must not apply", account.Type, got, want)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	l, accounts := newTestLedger(t)
+	ctx := context.Background()
+	funding, _ := l.SystemAccount(ctx, "funding", "USD")
+	alice := openWallet(t, accounts, "USD")
+
+	// balances must match their postings while entries are posted concurrently
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			post(ctx, l, funding.ID, alice.ID, "1.00")
+		}()
+	}
+	for range 20 {
+		r, err := l.Reconcile(ctx, alice.ID)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if r.StoredBalance != r.DerivedBalance {
+			t.Fatalf("stored %s, derived %s while posting", r.StoredBalance, r.DerivedBalance)
+		}
+	}
+	wg.Wait()
+
+	// a balance changed behind the ledger's back shows up as a mismatch
+	accounts.mu.Lock()
+	accounts.accounts[alice.ID].Balance.Amount += 1
+	accounts.mu.Unlock()
+	r, err := l.Reconcile(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if r.StoredBalance.Decimal() != "20.01" || r.DerivedBalance.Decimal() != "20.00" {
+		t.Fatalf("stored %s, derived %s, want 20.01 and 20.00", r.StoredBalance, r.DerivedBalance)
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PasswordResetRepository struct {
+	clock  clock.Clock
+	mu     sync.Mutex
+	tokens map[primitive.ObjectID]*models.PasswordResetToken
+}
+
+func NewPasswordResetRepo(clk clock.Clock) *PasswordResetRepository {
+	return &PasswordResetRepository{clock: clk, tokens: map[primitive.ObjectID]*models.PasswordResetToken{}}
+}
+
+var _ repositories.PasswordResetRepository = (*PasswordResetRepository)(nil)
+
+func (r *PasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = r.clock.Now()
+
+	stored := *token
+	r.mu.Lock()
+	r.tokens[token.ID] = &stored
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *PasswordResetRepository) CountSince(ctx context.Context, userID primitive.ObjectID, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int64
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			token.UsedAt = &now
+			consumed := *token
+			return &consumed, nil
+		}
+	}
+	return nil, repositories.ErrResetTokenInvalid
+}
+
+func (r *PasswordResetRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
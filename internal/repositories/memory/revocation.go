@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+type RevocationRepository struct {
+	mu          sync.Mutex
+	revocations map[string]models.Revocation
+}
+
+func NewRevocationRepo() *RevocationRepository {
+	return &RevocationRepository{revocations: map[string]models.Revocation{}}
+}
+
+var _ repositories.RevocationRepository = (*RevocationRepository)(nil)
+
+// Revoke keeps the latest revocation and expiry times for the key, like the $max upsert.
+func (r *RevocationRepository) Revoke(ctx context.Context, revocation *models.Revocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.revocations[revocation.ID]
+	if !ok {
+		r.revocations[revocation.ID] = *revocation
+		return nil
+	}
+	if revocation.RevokedAt.After(stored.RevokedAt) {
+		stored.RevokedAt = revocation.RevokedAt
+	}
+	if revocation.ExpiresAt.After(stored.ExpiresAt) {
+		stored.ExpiresAt = revocation.ExpiresAt
+	}
+	r.revocations[revocation.ID] = stored
+	return nil
+}
+
+func (r *RevocationRepository) FindMany(ctx context.Context, ids []string) ([]models.Revocation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revocations := []models.Revocation{}
+	for _, id := range ids {
+		if revocation, ok := r.revocations[id]; ok {
+			revocations = append(revocations, revocation)
+		}
+	}
+	return revocations, nil
+}
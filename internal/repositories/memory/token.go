@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TokenRepository struct {
+	clock  clock.Clock
+	mu     sync.Mutex
+	tokens map[primitive.ObjectID]*models.RefreshToken
+}
+
+func NewTokenRepo(clk clock.Clock) *TokenRepository {
+	return &TokenRepository{clock: clk, tokens: map[primitive.ObjectID]*models.RefreshToken{}}
+}
+
+var _ repositories.TokenRepository = (*TokenRepository)(nil)
+
+func copyToken(t *models.RefreshToken) *models.RefreshToken {
+	c := *t
+	return &c
+}
+
+// Create refuses a duplicate hash, as the unique index on token_hash does.
+func (r *TokenRepository) Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.tokens {
+		if existing.TokenHash == token.TokenHash {
+			return nil, errors.New("duplicate refresh token hash")
+		}
+	}
+
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	token.CreatedAt = r.clock.Now()
+	r.tokens[token.ID] = copyToken(token)
+	return token, nil
+}
+
+func (r *TokenRepository) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			return copyToken(token), nil
+		}
+	}
+	return nil, repositories.ErrTokenNotFound
+}
+
+func (r *TokenRepository) MarkRotated(ctx context.Context, id, replacedBy primitive.ObjectID, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.RotatedAt != nil || token.RevokedAt != nil {
+		return repositories.ErrTokenAlreadyRotated
+	}
+	token.RotatedAt = &now
+	token.ReplacedBy = replacedBy
+	return nil
+}
+
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID, now time.Time) error {
+	r.revoke(func(t *models.RefreshToken) bool { return t.FamilyID == familyID }, now)
+	return nil
+}
+
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	r.revoke(func(t *models.RefreshToken) bool { return t.UserID == userID }, now)
+	return nil
+}
+
+func (r *TokenRepository) revoke(match func(t *models.RefreshToken) bool, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TransactionRepository struct {
+	clock        clock.Clock
+	mu           sync.RWMutex
+	transactions map[primitive.ObjectID]models.Transaction
+}
+
+func NewTransactionRepo(clk clock.Clock) *TransactionRepository {
+	return &TransactionRepository{clock: clk, transactions: map[primitive.ObjectID]models.Transaction{}}
+}
+
+var _ repositories.TransactionRepository = (*TransactionRepository)(nil)
+
+func (r *TransactionRepository) Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error) {
+	if tx.ID.IsZero() {
+		tx.ID = primitive.NewObjectID()
+	}
+	tx.CreatedAt = r.clock.Now()
+
+	r.mu.Lock()
+	r.transactions[tx.ID] = *tx
+	r.mu.Unlock()
+	return tx, nil
+}
+
+func (r *TransactionRepository) FindByID(ctx context.Context, id string) (*models.Transaction, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, repositories.ErrTransactionNotFound
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if tx, ok := r.transactions[objectID]; ok {
+		return &tx, nil
+	}
+	return nil, repositories.ErrTransactionNotFound
+}
+
+func (r *TransactionRepository) ListByAccounts(ctx context.Context, accountIDs []primitive.ObjectID, page int, limit int) ([]models.Transaction, error) {
+	return r.list(func(tx models.Transaction) bool {
+		return slices.Contains(accountIDs, tx.FromAccount) || slices.Contains(accountIDs, tx.ToAccount)
+	}, page, limit), nil
+}
+
+func (r *TransactionRepository) ListRecent(ctx context.Context, page int, limit int) ([]models.Transaction, error) {
+	return r.list(func(models.Transaction) bool { return true }, page, limit), nil
+}
+
+// list returns the matching transactions newest first.
+func (r *TransactionRepository) list(match func(tx models.Transaction) bool, page int, limit int) []models.Transaction {
+	r.mu.RLock()
+	transactions := []models.Transaction{}
+	for _, tx := range r.transactions {
+		if match(tx) {
+			transactions = append(transactions, tx)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(transactions, func(i, j int) bool { return transactions[i].CreatedAt.After(transactions[j].CreatedAt) })
+	return paginate(transactions, page, limit)
+}
+
+func (r *TransactionRepository) SumOutgoing(ctx context.Context, accountID primitive.ObjectID, since time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var total int64
+	for _, tx := range r.transactions {
+		if tx.FromAccount == accountID && tx.Status == models.TransactionStatusCompleted && !tx.CreatedAt.Before(since) {
+			total += tx.Amount.Amount
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+// Transactor runs units of work one at a time. Nothing is rolled back when fn fails, so
+// it only stands in for Mongo where the last write of fn is the one that can fail.
+type Transactor struct {
+	mu sync.Mutex
+}
+
+func NewTransactor() *Transactor {
+	return &Transactor{}
+}
+
+var _ repositories.Transactor = (*Transactor)(nil)
+
+func (t *Transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(ctx)
+}
@@ -0,0 +1,302 @@
+// Package memory implements the repository interfaces with mutex-guarded maps, so
+// services can be exercised in tests without a MongoDB server. Records are copied on
+// the way in and out, as a database round trip would, and timestamps come from the clock
+// each store is given so tests can move time forward.
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserRepository struct {
+	clock clock.Clock
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]*models.User
+}
+
+func NewUserRepo(clk clock.Clock) *UserRepository {
+	return &UserRepository{clock: clk, users: map[primitive.ObjectID]*models.User{}}
+}
+
+var _ repositories.UserRepository = (*UserRepository)(nil)
+
+func copyUser(u *models.User) *models.User {
+	c := *u
+	c.RecoveryCodes = slices.Clone(u.RecoveryCodes)
+	return &c
+}
+
+// CreateUser refuses an email that is already registered, as the unique index does.
+func (r *UserRepository) CreateUser(user *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.Email = models.NormalizeEmail(user.Email)
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return nil, repositories.ErrEmailTaken
+		}
+	}
+
+	user.ID = primitive.NewObjectID()
+	user.CreatedAt = r.clock.Now()
+	user.UpdatedAt = r.clock.Now()
+	user.KYCStatus = "unverified"
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
+	r.users[user.ID] = copyUser(user)
+	return user, nil
+}
+
+func (r *UserRepository) FindByID(id string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if user, ok := r.users[objectID]; ok {
+		return copyUser(user), nil
+	}
+	return nil, repositories.ErrUserNotFound
+}
+
+func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+	email = models.NormalizeEmail(email)
+	return r.findOne(func(u *models.User) bool { return u.Email == email })
+}
+
+func (r *UserRepository) FindByKYCReference(reference string) (*models.User, error) {
+	return r.findOne(func(u *models.User) bool { return u.KYCReference == reference })
+}
+
+func (r *UserRepository) findOne(match func(u *models.User) bool) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if match(user) {
+			return copyUser(user), nil
+		}
+	}
+	return nil, repositories.ErrUserNotFound
+}
+
+// update applies fn to the stored user under the write lock. A false from fn means the
+// filter did not match and nothing changed.
+func (r *UserRepository) update(userId string, fn func(u *models.User) bool) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[objectID]
+	if !ok {
+		return false, nil
+	}
+	return fn(user), nil
+}
+
+func (r *UserRepository) UpdateKYCStatus(id string, status string) (*models.User, error) {
+	switch status {
+	case models.KYCStatusUnverified, models.KYCStatusPending, models.KYCStatusVerified, models.KYCStatusRejected:
+	default:
+		return nil, errors.New("invalid KYC status")
+	}
+
+	found, err := r.update(id, func(u *models.User) bool {
+		u.KYCStatus = status
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, repositories.ErrUserNotFound
+	}
+	return r.FindByID(id)
+}
+
+func (r *UserRepository) SetKYCSubmission(userId string, reference string, caseID primitive.ObjectID) error {
+	_, err := r.update(userId, func(u *models.User) bool {
+		now := r.clock.Now()
+		u.KYCStatus = models.KYCStatusPending
+		u.KYCReference = reference
+		u.KYCCaseID = caseID
+		u.KYCSubmittedAt = &now
+		u.KYCReviewedAt = nil
+		u.KYCRejectionReason = ""
+		u.UpdatedAt = now
+		return true
+	})
+	return err
+}
+
+func (r *UserRepository) ApplyKYCDecision(ctx context.Context, reference string, status string, reason string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.KYCReference != reference || u.KYCStatus != models.KYCStatusPending {
+			continue
+		}
+		now := r.clock.Now()
+		u.KYCStatus = status
+		u.KYCReviewedAt = &now
+		u.UpdatedAt = now
+		if reason != "" {
+			u.KYCRejectionReason = reason
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *UserRepository) SetKYCTier(ctx context.Context, userId string, tier int) error {
+	_, err := r.update(userId, func(u *models.User) bool {
+		u.KYCTier = tier
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	return err
+}
+
+func (r *UserRepository) UpdateUserPassword(userId string, passwordHash string) error {
+	_, err := r.update(userId, func(u *models.User) bool {
+		u.Password = passwordHash
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	return err
+}
+
+func (r *UserRepository) ListUsersWithKYCStatus(status string, page int, limit int) ([]models.User, error) {
+	return r.SearchUsers(repositories.UserFilter{KYCStatus: status}, page, limit)
+}
+
+func (r *UserRepository) SearchUsers(f repositories.UserFilter, page int, limit int) ([]models.User, error) {
+	query := strings.ToLower(f.Query)
+
+	r.mu.RLock()
+	users := []models.User{}
+	for _, u := range r.users {
+		if f.KYCStatus != "" && u.KYCStatus != f.KYCStatus {
+			continue
+		}
+		if f.Role != "" && u.Role != f.Role {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(u.Email), query) && !strings.Contains(strings.ToLower(u.FullName), query) {
+			continue
+		}
+		users = append(users, *copyUser(u))
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+	return paginate(users, page, limit), nil
+}
+
+func (r *UserRepository) SetRole(ctx context.Context, userId string, role string) error {
+	found, err := r.update(userId, func(u *models.User) bool {
+		u.Role = role
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	if err == nil && !found {
+		return repositories.ErrUserNotFound
+	}
+	return err
+}
+
+func (r *UserRepository) MarkEmailVerified(userId string, email string) (bool, error) {
+	return r.update(userId, func(u *models.User) bool {
+		if u.Email != models.NormalizeEmail(email) {
+			return false
+		}
+		now := r.clock.Now()
+		u.EmailVerified = true
+		u.EmailVerifiedAt = &now
+		u.UpdatedAt = now
+		return true
+	})
+}
+
+func (r *UserRepository) SetPendingTOTPSecret(userId string, secret string) error {
+	_, err := r.update(userId, func(u *models.User) bool {
+		u.TOTPPendingSecret = secret
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	return err
+}
+
+func (r *UserRepository) EnableTwoFactor(userId string, secret string, step int64, recoveryCodeHashes []string) error {
+	_, err := r.update(userId, func(u *models.User) bool {
+		u.TwoFactorEnabled = true
+		u.TOTPSecret = secret
+		u.TOTPLastStep = step
+		u.RecoveryCodes = slices.Clone(recoveryCodeHashes)
+		u.TOTPPendingSecret = ""
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	return err
+}
+
+func (r *UserRepository) DisableTwoFactor(userId string) error {
+	_, err := r.update(userId, func(u *models.User) bool {
+		u.TwoFactorEnabled = false
+		u.TOTPSecret = ""
+		u.TOTPPendingSecret = ""
+		u.TOTPLastStep = 0
+		u.RecoveryCodes = nil
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+	return err
+}
+
+func (r *UserRepository) AdvanceTOTPStep(userId string, step int64) (bool, error) {
+	return r.update(userId, func(u *models.User) bool {
+		if u.TOTPLastStep >= step {
+			return false
+		}
+		u.TOTPLastStep = step
+		return true
+	})
+}
+
+func (r *UserRepository) ConsumeRecoveryCode(userId string, codeHash string) (bool, error) {
+	return r.update(userId, func(u *models.User) bool {
+		i := slices.Index(u.RecoveryCodes, codeHash)
+		if i < 0 {
+			return false
+		}
+		u.RecoveryCodes = slices.Delete(slices.Clone(u.RecoveryCodes), i, i+1)
+		u.UpdatedAt = r.clock.Now()
+		return true
+	})
+}
+
+// paginate returns the 1-based page of items, as skip/limit would.
+func paginate[T any](items []T, page int, limit int) []T {
+	start := (page - 1) * limit
+	if start < 0 || start >= len(items) {
+		return []T{}
+	}
+	return items[start:min(start+limit, len(items))]
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrResetTokenInvalid = errors.New("reset token is invalid or has expired")
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	CountSince(ctx context.Context, userID primitive.ObjectID, since time.Time) (int64, error)
+	Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error)
+	InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+}
+
+type MongoPasswordResetRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPasswordResetRepo(db *mongo.Database, collectionName string) *MongoPasswordResetRepository {
+	return &MongoPasswordResetRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoPasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// CountSince counts the reset tokens issued to a user after since
+func (r *MongoPasswordResetRepository) CountSince(ctx context.Context, userID primitive.ObjectID, since time.Time) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "created_at": bson.M{"$gt": since}})
+}
+
+// Consume marks a live token as used and returns it; a token can only be consumed once
+func (r *MongoPasswordResetRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (*models.PasswordResetToken, error) {
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var token models.PasswordResetToken
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": now}}, opts).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrResetTokenInvalid
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// InvalidateForUser burns every outstanding token, e.g. once the password has been changed
+func (r *MongoPasswordResetRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": now}})
+	return err
+}
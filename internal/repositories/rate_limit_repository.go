@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RateLimitRepository keeps token buckets in Mongo so every instance behind a load
+// balancer draws from the same budget.
+type RateLimitRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRateLimitRepo(db *mongo.Database, collectionName string) *RateLimitRepository {
+	return &RateLimitRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Take refills the bucket for the time elapsed since its last use and removes one token
+// if there is one, all in a single atomic update. rate is in tokens per second.
+func (r *RateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int, now time.Time) (float64, bool, error) {
+	ttl := time.Duration(float64(burst) / rate * float64(time.Second))
+	elapsedSeconds := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+		1000,
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{
+				float64(burst),
+				bson.M{"$add": bson.A{
+					bson.M{"$ifNull": bson.A{"$tokens", float64(burst)}},
+					bson.M{"$multiply": bson.A{bson.M{"$max": bson.A{elapsedSeconds, 0}}, rate}},
+				}},
+			}},
+		}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens":     bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"updated_at": now,
+			"expires_at": now.Add(ttl),
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var bucket models.RateLimitBucket
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	if mongo.IsDuplicateKeyError(err) {
+		// two instances created the bucket at once; the loser simply updates it
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return bucket.Tokens, bucket.Allowed, nil
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RevocationRepository interface {
+	Revoke(ctx context.Context, revocation *models.Revocation) error
+	FindMany(ctx context.Context, ids []string) ([]models.Revocation, error)
+}
+
+type MongoRevocationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRevocationRepo(db *mongo.Database, collectionName string) *MongoRevocationRepository {
+	return &MongoRevocationRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Revoke upserts the record, keeping the latest revocation time for the key
+func (r *MongoRevocationRepository) Revoke(ctx context.Context, revocation *models.Revocation) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": revocation.ID},
+		bson.M{"$max": bson.M{"revoked_at": revocation.RevokedAt, "expires_at": revocation.ExpiresAt}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// FindMany returns the revocations that exist among ids
+func (r *MongoRevocationRepository) FindMany(ctx context.Context, ids []string) ([]models.Revocation, error) {
+	revocations := []models.Revocation{}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &revocations); err != nil {
+		return nil, err
+	}
+	return revocations, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrTokenNotFound       = errors.New("refresh token not found")
+	ErrTokenAlreadyRotated = errors.New("refresh token already rotated")
+)
+
+// TokenRepository stores refresh tokens by hash, grouped into rotation families.
+type TokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error)
+	FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	MarkRotated(ctx context.Context, id, replacedBy primitive.ObjectID, now time.Time) error
+	RevokeFamily(ctx context.Context, familyID primitive.ObjectID, now time.Time) error
+	RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+}
+
+type MongoTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTokenRepo(db *mongo.Database, collectionName string) *MongoTokenRepository {
+	return &MongoTokenRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoTokenRepository) Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	if token.ID.IsZero() {
+		token.ID = primitive.NewObjectID()
+	}
+	token.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *MongoTokenRepository) FindByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRotated retires a live token in favour of its replacement. Only one caller can win;
+// the loser gets ErrTokenAlreadyRotated.
+func (r *MongoTokenRepository) MarkRotated(ctx context.Context, id, replacedBy primitive.ObjectID, now time.Time) error {
+	filter := bson.M{
+		"_id":        id,
+		"rotated_at": bson.M{"$exists": false},
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"rotated_at": now, "replaced_by": replacedBy}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrTokenAlreadyRotated
+	}
+	return nil
+}
+
+func (r *MongoTokenRepository) RevokeFamily(ctx context.Context, familyID primitive.ObjectID, now time.Time) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"family_id": familyID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	return err
+}
+
+func (r *MongoTokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	return err
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+type TransactionRepository interface {
+	Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
+	FindByID(ctx context.Context, id string) (*models.Transaction, error)
+	ListByAccounts(ctx context.Context, accountIDs []primitive.ObjectID, page int, limit int) ([]models.Transaction, error)
+	SumOutgoing(ctx context.Context, accountID primitive.ObjectID, since time.Time) (int64, error)
+	ListRecent(ctx context.Context, page int, limit int) ([]models.Transaction, error)
+}
+
+type MongoTransactionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTransactionRepo(db *mongo.Database, collectionName string) *MongoTransactionRepository {
+	return &MongoTransactionRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *MongoTransactionRepository) Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error) {
+	if tx.ID.IsZero() {
+		tx.ID = primitive.NewObjectID()
+	}
+	tx.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (r *MongoTransactionRepository) FindByID(ctx context.Context, id string) (*models.Transaction, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	var tx models.Transaction
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&tx)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// ListByAccounts returns transactions where any of the accounts is sender or receiver, newest first
+func (r *MongoTransactionRepository) ListByAccounts(ctx context.Context, accountIDs []primitive.ObjectID, page int, limit int) ([]models.Transaction, error) {
+	transactions := []models.Transaction{}
+	if len(accountIDs) == 0 {
+		return transactions, nil
+	}
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"from_account": bson.M{"$in": accountIDs}},
+		bson.M{"to_account": bson.M{"$in": accountIDs}},
+	}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// SumOutgoing adds up the completed amounts that left accountID since the given time, in
+// the account's minor units
+func (r *MongoTransactionRepository) SumOutgoing(ctx context.Context, accountID primitive.ObjectID, since time.Time) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"from_account": accountID,
+			"status":       models.TransactionStatusCompleted,
+			"created_at":   bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount.minor_units"}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Total int64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Total, cursor.Err()
+}
+
+// ListRecent returns all transactions, newest first, for back-office browsing
+func (r *MongoTransactionRepository) ListRecent(ctx context.Context, page int, limit int) ([]models.Transaction, error) {
+	transactions := []models.Transaction{}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &transactions); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
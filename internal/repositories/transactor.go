@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs a unit of work atomically. Repository calls made with the ctx passed to
+// fn take part in the transaction.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoTransactor runs a unit of work inside a MongoDB multi-document transaction.
+type MongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(client *mongo.Client) *MongoTransactor {
+	return &MongoTransactor{client: client}
+}
+
+// WithTransaction commits fn atomically. The driver retries fn on TransientTransactionError
+// and retries the commit on UnknownTransactionCommitResult, so fn must be safe to re-run.
+func (t *MongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
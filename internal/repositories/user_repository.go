@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/samoray1998/fintech-wallet/internal/models"
@@ -10,28 +11,63 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"golang.org/x/crypto/bcrypt"
 )
 
-type UserRepository struct {
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already registered")
+)
+
+// UserRepository is the user store the services depend on. MongoUserRepository backs it
+// in production; the memory package has an in-process version for tests.
+type UserRepository interface {
+	CreateUser(user *models.User) (*models.User, error)
+	FindByID(id string) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	UpdateKYCStatus(id string, status string) (*models.User, error)
+	SetKYCSubmission(userId string, reference string, caseID primitive.ObjectID) error
+	ApplyKYCDecision(ctx context.Context, reference string, status string, reason string) (bool, error)
+	SetKYCTier(ctx context.Context, userId string, tier int) error
+	FindByKYCReference(reference string) (*models.User, error)
+	UpdateUserPassword(userId string, passwordHash string) error
+	ListUsersWithKYCStatus(status string, page int, limit int) ([]models.User, error)
+	SearchUsers(f UserFilter, page int, limit int) ([]models.User, error)
+	SetRole(ctx context.Context, userId string, role string) error
+	MarkEmailVerified(userId string, email string) (bool, error)
+	SetPendingTOTPSecret(userId string, secret string) error
+	EnableTwoFactor(userId string, secret string, step int64, recoveryCodeHashes []string) error
+	DisableTwoFactor(userId string) error
+	AdvanceTOTPStep(userId string, step int64) (bool, error)
+	ConsumeRecoveryCode(userId string, codeHash string) (bool, error)
+}
+
+type MongoUserRepository struct {
 	collection *mongo.Collection
 }
 
-func NewUserRepo(db *mongo.Database, collectionName string) *UserRepository {
-	return &UserRepository{
+func NewUserRepo(db *mongo.Database, collectionName string) *MongoUserRepository {
+	return &MongoUserRepository{
 		collection: db.Collection(collectionName),
 	}
 }
 
 // / CreateUser with new hash password
-func (r *UserRepository) CreateUser(user *models.User) (*models.User, error) {
+func (r *MongoUserRepository) CreateUser(user *models.User) (*models.User, error) {
 	user.ID = primitive.NewObjectID()
+	user.Email = models.NormalizeEmail(user.Email)
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 	user.KYCStatus = "unverified"
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
 
 	_, err := r.collection.InsertOne(context.Background(), user)
 	if err != nil {
+		// the unique email index catches registrations that race past the service's check
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailTaken
+		}
 		return nil, err
 	}
 
@@ -40,31 +76,31 @@ func (r *UserRepository) CreateUser(user *models.User) (*models.User, error) {
 
 ///findBy id
 
-func (r *UserRepository) FindByID(id string) (*models.User, error) {
+func (r *MongoUserRepository) FindByID(id string) (*models.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
 	var user models.User
-	err = r.collection.FindOne(context.Background(), bson.M{"id": objectID}).Decode(&user)
+	err = r.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-// FindByEmail finds a user by email (for authentication)
+// FindByEmail finds a user by email (for authentication), in any letter case
 
-func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+func (r *MongoUserRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
 
-	err := r.collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
+	err := r.collection.FindOne(context.Background(), bson.M{"email": models.NormalizeEmail(email)}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -74,7 +110,7 @@ func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 
 ///  UpdateKYCStatus func
 
-func (r *UserRepository) UpdateKYCStatus(id string, status string) (*models.User, error) {
+func (r *MongoUserRepository) UpdateKYCStatus(id string, status string) (*models.User, error) {
 
 	objctId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -85,7 +121,7 @@ func (r *UserRepository) UpdateKYCStatus(id string, status string) (*models.User
 	err = r.collection.FindOne(context.Background(), bson.M{"_id": objctId}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -98,28 +134,86 @@ func (r *UserRepository) UpdateKYCStatus(id string, status string) (*models.User
 	if !validStatuses[status] {
 		return nil, errors.New("invalid KYC status")
 	}
-	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objctId}, bson.M{"$set": bson.M{"kyc_status": status, "updated_at": time.Now().Unix()}})
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objctId}, bson.M{"$set": bson.M{"kyc_status": status, "updated_at": time.Now()}})
 	err = r.collection.FindOne(context.Background(), bson.M{"_id": objctId}).Decode(&user)
 
 	return user, err
 
 }
 
-/// update password
-
-func (r *UserRepository) UpdateUserPassword(userId string, newPass string) error {
+// SetKYCSubmission records a provider submission and moves the user to pending
+func (r *MongoUserRepository) SetKYCSubmission(userId string, reference string, caseID primitive.ObjectID) error {
 	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	now := time.Now()
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectId}, bson.M{
+		"$set": bson.M{
+			"kyc_status":       models.KYCStatusPending,
+			"kyc_reference":    reference,
+			"kyc_case_id":      caseID,
+			"kyc_submitted_at": now,
+			"updated_at":       now,
+		},
+		"$unset": bson.M{"kyc_reviewed_at": "", "kyc_rejection_reason": ""},
+	})
+	return err
+}
+
+// ApplyKYCDecision moves the user holding reference from pending to verified or rejected,
+// reporting false when no pending user has that reference
+func (r *MongoUserRepository) ApplyKYCDecision(ctx context.Context, reference string, status string, reason string) (bool, error) {
+	now := time.Now()
+	set := bson.M{"kyc_status": status, "kyc_reviewed_at": now, "updated_at": now}
+	if reason != "" {
+		set["kyc_rejection_reason"] = reason
+	}
 
+	res, err := r.collection.UpdateOne(ctx,
+		bson.M{"kyc_reference": reference, "kyc_status": models.KYCStatusPending},
+		bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (r *MongoUserRepository) SetKYCTier(ctx context.Context, userId string, tier int) error {
+	objectId, err := primitive.ObjectIDFromHex(userId)
 	if err != nil {
 		return errors.New("invalid user ID")
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPass), bcrypt.DefaultCost)
 
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectId}, bson.M{"$set": bson.M{"kyc_tier": tier, "updated_at": time.Now()}})
+	return err
+}
+
+func (r *MongoUserRepository) FindByKYCReference(reference string) (*models.User, error) {
+	var user models.User
+
+	err := r.collection.FindOne(context.Background(), bson.M{"kyc_reference": reference}).Decode(&user)
 	if err != nil {
-		return err
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
 	}
 
-	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectId}, bson.M{"$set": bson.M{"password": hashedPassword, "updatedAt": time.Now()}})
+	return &user, nil
+}
+
+/// update password, hashing is the caller's job so the configured bcrypt cost applies
+
+func (r *MongoUserRepository) UpdateUserPassword(userId string, passwordHash string) error {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectId}, bson.M{"$set": bson.M{"password_hash": passwordHash, "updated_at": time.Now()}})
 
 	if err != nil {
 		return err
@@ -131,17 +225,38 @@ func (r *UserRepository) UpdateUserPassword(userId string, newPass string) error
 
 //ListUsersWithKYCStatus
 
-func (r *UserRepository) ListUsersWithKYCStatus(status string, page int, limit int) ([]models.User, error) {
+func (r *MongoUserRepository) ListUsersWithKYCStatus(status string, page int, limit int) ([]models.User, error) {
+	return r.SearchUsers(UserFilter{KYCStatus: status}, page, limit)
+}
 
-	var users []models.User
+// UserFilter narrows SearchUsers; empty fields match everything
+type UserFilter struct {
+	Query     string // case-insensitive substring of email or full name
+	KYCStatus string
+	Role      string
+}
+
+func (r *MongoUserRepository) SearchUsers(f UserFilter, page int, limit int) ([]models.User, error) {
+
+	users := []models.User{}
 
 	filter := bson.M{}
 
-	if status != "" {
-		filter["kyc_status"] = status
+	if f.KYCStatus != "" {
+		filter["kyc_status"] = f.KYCStatus
+	}
+	if f.Role != "" {
+		filter["role"] = f.Role
+	}
+	if f.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(f.Query), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"email": pattern}, bson.M{"full_name": pattern}}
 	}
 
-	opts := options.Find().SetSkip(int64(page - 1)).SetLimit(int64(limit))
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
 
 	cursor, err := r.collection.Find(context.Background(), filter, opts)
 	if err != nil {
@@ -154,3 +269,115 @@ func (r *UserRepository) ListUsersWithKYCStatus(status string, page int, limit i
 	}
 	return users, nil
 }
+
+func (r *MongoUserRepository) SetRole(ctx context.Context, userId string, role string) error {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectId}, bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified flags the email as verified, but only while the user still has that
+// address, so a link sent before an email change cannot verify the new one
+func (r *MongoUserRepository) MarkEmailVerified(userId string, email string) (bool, error) {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	now := time.Now()
+	res, err := r.collection.UpdateOne(context.Background(),
+		bson.M{"_id": objectId, "email": models.NormalizeEmail(email)},
+		bson.M{"$set": bson.M{"email_verified": true, "email_verified_at": now, "updated_at": now}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+/// two-factor authentication
+
+func (r *MongoUserRepository) SetPendingTOTPSecret(userId string, secret string) error {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectId}, bson.M{"$set": bson.M{"totp_pending_secret": secret, "updated_at": time.Now()}})
+	return err
+}
+
+func (r *MongoUserRepository) EnableTwoFactor(userId string, secret string, step int64, recoveryCodeHashes []string) error {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectId}, bson.M{
+		"$set": bson.M{
+			"two_factor_enabled": true,
+			"totp_secret":        secret,
+			"totp_last_step":     step,
+			"recovery_codes":     recoveryCodeHashes,
+			"updated_at":         time.Now(),
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+	return err
+}
+
+func (r *MongoUserRepository) DisableTwoFactor(userId string) error {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectId}, bson.M{
+		"$set":   bson.M{"two_factor_enabled": false, "updated_at": time.Now()},
+		"$unset": bson.M{"totp_secret": "", "totp_pending_secret": "", "totp_last_step": "", "recovery_codes": ""},
+	})
+	return err
+}
+
+// AdvanceTOTPStep records step as used; it fails if that step (or a later one) was already accepted
+func (r *MongoUserRepository) AdvanceTOTPStep(userId string, step int64) (bool, error) {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectId, "$or": bson.A{
+		bson.M{"totp_last_step": bson.M{"$exists": false}},
+		bson.M{"totp_last_step": bson.M{"$lt": step}},
+	}}
+	res, err := r.collection.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// ConsumeRecoveryCode removes a recovery code hash, reporting whether it was still unused
+func (r *MongoUserRepository) ConsumeRecoveryCode(userId string, codeHash string) (bool, error) {
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	res, err := r.collection.UpdateOne(context.Background(),
+		bson.M{"_id": objectId, "recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"recovery_codes": codeHash}, "$set": bson.M{"updated_at": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
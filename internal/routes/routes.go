@@ -4,39 +4,115 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/samoray1998/fintech-wallet/internal/controllers"
 	"github.com/samoray1998/fintech-wallet/internal/middlewares"
+	"github.com/samoray1998/fintech-wallet/internal/models"
 )
 
 func SetupRouter(
 	authMiddleware *middlewares.AuthMiddleware,
+	adminAudit *middlewares.AdminAuditMiddleware,
+	metrics *middlewares.MetricsMiddleware,
+	rateLimiter *middlewares.RateLimiter,
+	idempotency *middlewares.IdempotencyMiddleware,
 	authController *controllers.AuthController,
 	userController *controllers.UserController,
-	//accountController *controllers.AccountController,
-	//transactionController *controllers.TransactionController,
-	//rateController *controllers.RateController,
-	rateLimit int,
-) *gin.Engine {
+	accountController *controllers.AccountController,
+	transactionController *controllers.TransactionController,
+	rateController *controllers.RateController,
+	fxController *controllers.FXController,
+	passwordController *controllers.PasswordController,
+	adminController *controllers.AdminController,
+	kycController *controllers.KYCController,
+	healthController *controllers.HealthController,
+	limits middlewares.RateLimitPolicies,
+	trustedProxies []string,
+) (*gin.Engine, error) {
 	router := gin.New()
 
-	// Global middleware
-	router.Use(gin.Recovery())
-	//router.Use(middlewares.RateLimiter(rateLimit))
+	// ClientIP, which the per-IP limits key on, believes X-Forwarded-For only from these
+	// proxies; with none it is always the address of the connection
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
+	// Global middleware; recovery runs innermost so panics are logged as 500s
+	router.Use(middlewares.RequestID, middlewares.LoggingMiddleware(), metrics.Observe, gin.Recovery())
+
+	// Prometheus scrape endpoint and orchestrator probes
+	router.GET("/metrics", metrics.Serve)
+	router.GET("/healthz", healthController.Liveness)
+	router.GET("/readyz", healthController.Readiness)
+
+	// Credential endpoints, limited per IP more strictly than everything else
+	credentials := router.Group("/api/v1")
+	credentials.Use(rateLimiter.Limit(limits.Auth))
+	{
+		credentials.POST("/register", idempotency.Handle, authController.Register)
+		credentials.POST("/login", authController.Login)
+		credentials.POST("/auth/refresh", authController.Refresh)
+		credentials.POST("/auth/2fa/verify", authController.VerifyTwoFactor)
+		credentials.POST("/auth/password/forgot", passwordController.ForgotPassword)
+		credentials.POST("/auth/password/reset", passwordController.ResetPassword)
+		credentials.POST("/auth/email/verify", authController.VerifyEmail)
+	}
 
 	// Public routes
 	public := router.Group("/api/v1")
+	public.Use(rateLimiter.Limit(limits.Default))
 	{
-		public.POST("/register", authController.Register)
-		public.POST("/login", authController.Login)
-		//public.GET("/rates", rateController.GetCurrentRates)
+		public.GET("/rates", rateController.GetCurrentRates)
 	}
 
-	// Authenticated routes
+	// Authenticated routes, limited per user
 	private := router.Group("/api/v1")
-	private.Use(authMiddleware.Authenticate)
+	private.Use(authMiddleware.Authenticate, rateLimiter.Limit(limits.Default))
 	{
 		private.GET("/users/me", userController.GetProfile)
-		//private.POST("/accounts", accountController.CreateAccount)
-		//private.POST("/transactions", transactionController.CreateTransaction)
+		private.GET("/users/me/kyc", kycController.GetCase)
+		private.POST("/users/me/kyc", kycController.InitiateKYC)
+		private.POST("/users/me/kyc/documents", kycController.UploadDocument)
+		private.POST("/auth/logout", authController.Logout)
+		private.POST("/auth/logout-all", authController.LogoutAll)
+		private.POST("/auth/email/resend", authController.ResendVerification)
+		private.POST("/auth/2fa/enroll", authController.EnrollTwoFactor)
+		private.POST("/auth/2fa/activate", authController.ActivateTwoFactor)
+		private.POST("/auth/2fa/disable", authController.DisableTwoFactor)
+		private.POST("/accounts", authMiddleware.RequireVerifiedEmail, idempotency.Handle, accountController.CreateAccount)
+		private.GET("/accounts", accountController.ListAccounts)
+		private.GET("/accounts/:id", accountController.GetAccount)
+		private.GET("/accounts/:id/balance", accountController.GetBalance)
+		private.POST("/transactions", authMiddleware.RequireVerifiedEmail, idempotency.Handle, authMiddleware.RequireSecondFactor, transactionController.CreateTransaction)
+		private.POST("/fx/quotes", authMiddleware.RequireVerifiedEmail, fxController.CreateQuote)
+		private.POST("/fx/conversions", authMiddleware.RequireVerifiedEmail, idempotency.Handle, authMiddleware.RequireSecondFactor, fxController.ExecuteConversion)
+	}
+
+	// Provider callbacks, authenticated by signature and exempt from client rate limits
+	webhooks := router.Group("/api/v1/webhooks")
+	{
+		webhooks.POST("/kyc", kycController.Webhook)
+	}
+
+	// Staff back office: every request is checked against the caller's role and recorded
+	staff := []string{models.RoleSupport, models.RoleCompliance, models.RoleAdmin}
+	compliance := []string{models.RoleCompliance, models.RoleAdmin}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(authMiddleware.Authenticate, rateLimiter.Limit(limits.Default), authMiddleware.RequireRole(staff...), adminAudit.Record)
+	{
+		admin.GET("/users", adminController.ListUsers)
+		admin.GET("/users/:id", adminController.GetUser)
+		admin.POST("/users/:id/unlock", adminController.UnlockUser)
+		admin.PUT("/users/:id/role", authMiddleware.RequireRole(models.RoleAdmin), adminController.SetRole)
+		admin.GET("/transactions", adminController.ListTransactions)
+		admin.GET("/transactions/:id", adminController.GetTransaction)
+		admin.GET("/kyc/cases", authMiddleware.RequireRole(compliance...), adminController.ListKYCCases)
+		admin.GET("/kyc/cases/:id", authMiddleware.RequireRole(compliance...), adminController.GetKYCCase)
+		admin.GET("/kyc/cases/:id/documents/:docId", authMiddleware.RequireRole(compliance...), adminController.DownloadKYCDocument)
+		admin.POST("/kyc/cases/:id/decision", authMiddleware.RequireRole(compliance...), adminController.DecideKYCCase)
+		admin.POST("/accounts/:id/freeze", authMiddleware.RequireRole(compliance...), adminController.FreezeAccount)
+		admin.POST("/accounts/:id/unfreeze", authMiddleware.RequireRole(compliance...), adminController.UnfreezeAccount)
+		admin.GET("/actions", authMiddleware.RequireRole(models.RoleAdmin), adminController.ListActions)
+		admin.GET("/audit/verify", authMiddleware.RequireRole(models.RoleAdmin), adminController.VerifyAuditLog)
 	}
 
-	return router
+	return router, nil
 }
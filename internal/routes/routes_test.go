@@ -0,0 +1,351 @@
+package routes_test
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/testutil"
+	"github.com/samoray1998/fintech-wallet/internal/totp"
+)
+
+func TestRegisterKYCOpenAccountTransfer(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	alice.CompleteKYC()
+
+	var profile struct {
+		KYCStatus string `json:"kycStatus"`
+		KYCTier   int    `json:"kyc_tier"`
+	}
+	alice.Get("/api/v1/users/me").Expect(http.StatusOK).JSON(&profile)
+	if profile.KYCStatus != "verified" || profile.KYCTier != 1 {
+		t.Fatalf("expected verified identity tier, got %+v", profile)
+	}
+
+	bob := app.SignUp("Bob Example", "bob@example.com")
+	from := alice.OpenAccount("USD")
+	to := bob.OpenAccount("USD")
+	app.Fund(from, "500.00", "USD")
+
+	// 300 is above the email tier's single transfer limit, so this needs the KYC upgrade
+	var tx struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	alice.Transfer(from, to, "300.00", "USD").Expect(http.StatusCreated).JSON(&tx)
+	if tx.Status != "completed" {
+		t.Fatalf("expected completed transfer, got %q", tx.Status)
+	}
+
+	if got := alice.Balance(from); got != "200.00" {
+		t.Errorf("sender balance = %s, want 200.00", got)
+	}
+	if got := bob.Balance(to); got != "300.00" {
+		t.Errorf("recipient balance = %s, want 300.00", got)
+	}
+}
+
+func TestUnverifiedEmailCannotOpenAccount(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	app.Register("Carol Example", "carol@example.com", testutil.Password)
+	token := app.Login("carol@example.com", testutil.Password)
+
+	res := app.Do(testutil.Request{Method: http.MethodPost, Path: "/api/v1/accounts", Token: token, Body: map[string]string{"currency": "USD"}})
+	res.Expect(http.StatusForbidden)
+	if code := res.Map()["code"]; code != "email_unverified" {
+		t.Fatalf("expected email_unverified, got %v", code)
+	}
+}
+
+func TestDailyLimitResetsNextDay(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	bob := app.SignUp("Bob Example", "bob@example.com")
+	from := alice.OpenAccount("USD")
+	to := bob.OpenAccount("USD")
+	app.Fund(from, "600.00", "USD")
+
+	// the email tier allows 250.00 a day
+	alice.Transfer(from, to, "100.00", "USD").Expect(http.StatusCreated)
+	alice.Transfer(from, to, "100.00", "USD").Expect(http.StatusCreated)
+	res := alice.Transfer(from, to, "100.00", "USD").Expect(http.StatusForbidden)
+	if code := res.Map()["code"]; code != "daily_limit_exceeded" {
+		t.Fatalf("expected daily_limit_exceeded, got %v", code)
+	}
+
+	app.Clock.Advance(25 * time.Hour)
+
+	// the access token has expired along the way
+	alice.Get("/api/v1/accounts").Expect(http.StatusUnauthorized)
+	alice.Token = app.Login(alice.Email, testutil.Password)
+
+	alice.Transfer(from, to, "100.00", "USD").Expect(http.StatusCreated)
+	if got := alice.Balance(from); got != "300.00" {
+		t.Errorf("sender balance = %s, want 300.00", got)
+	}
+}
+
+func TestTransferRetryWithIdempotencyKeyIsReplayed(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	bob := app.SignUp("Bob Example", "bob@example.com")
+	from := alice.OpenAccount("USD")
+	to := bob.OpenAccount("USD")
+	app.Fund(from, "100.00", "USD")
+
+	body := map[string]any{
+		"from_account_id": from,
+		"to_account_id":   to,
+		"amount":          map[string]string{"value": "40.00", "currency": "USD"},
+	}
+	first := alice.PostIdempotent("/api/v1/transactions", "retry-1", body).Expect(http.StatusCreated).Map()
+	second := alice.PostIdempotent("/api/v1/transactions", "retry-1", body).Expect(http.StatusCreated).Map()
+
+	if first["id"] != second["id"] {
+		t.Fatalf("retry created a second transaction: %v and %v", first["id"], second["id"])
+	}
+	if got := alice.Balance(from); got != "60.00" {
+		t.Errorf("sender balance = %s, want 60.00", got)
+	}
+}
+
+func TestQuoteRefusedOnStaleRates(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	usd := alice.OpenAccount("USD")
+	eur := alice.OpenAccount("EUR")
+	body := map[string]any{
+		"from_account_id": usd,
+		"to_account_id":   eur,
+		"amount":          map[string]string{"value": "10.00", "currency": "USD"},
+	}
+	alice.Post("/api/v1/fx/quotes", body).Expect(http.StatusCreated)
+
+	// the rate service keeps serving the last snapshot, but it is too old to price from
+	app.Rates.SetError(errors.New("provider down"))
+	app.Clock.Advance(3 * time.Hour)
+	alice.Token = app.Login(alice.Email, testutil.Password)
+
+	alice.Post("/api/v1/fx/quotes", body).Expect(http.StatusServiceUnavailable)
+	alice.Get("/api/v1/rates").Expect(http.StatusOK)
+}
+
+func TestQuoteRefusedOnRatesPublishedTooLongAgo(t *testing.T) {
+	app := testutil.NewApp(t)
+
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	usd := alice.OpenAccount("USD")
+	eur := alice.OpenAccount("EUR")
+	body := map[string]any{
+		"from_account_id": usd,
+		"to_account_id":   eur,
+		"amount":          map[string]string{"value": "10.00", "currency": "USD"},
+	}
+
+	// the provider answers, but with rates older than the quote limit of two hours
+	app.Rates.SetAge(3 * time.Hour)
+	alice.Post("/api/v1/fx/quotes", body).Expect(http.StatusServiceUnavailable)
+	alice.Get("/api/v1/rates").Expect(http.StatusOK)
+
+	app.Rates.SetAge(time.Minute)
+	app.Clock.Advance(2 * time.Hour)
+	alice.Token = app.Login(alice.Email, testutil.Password)
+	alice.Post("/api/v1/fx/quotes", body).Expect(http.StatusCreated)
+}
+
+func TestForgotPasswordAnswersAlikeForEveryAddress(t *testing.T) {
+	app := testutil.NewApp(t)
+	alice := app.SignUp("Alice Example", "alice@example.com")
+
+	forgot := func(email string) map[string]any {
+		return app.Do(testutil.Request{Method: http.MethodPost, Path: "/api/v1/auth/password/forgot", Body: map[string]string{"email": email}}).
+			Expect(http.StatusAccepted).Map()
+	}
+
+	// a mail outage must not tell registered addresses apart either
+	app.Mail.SetError(errors.New("smtp unavailable"))
+	registered, unknown := forgot(alice.Email), forgot("nobody@example.com")
+	if registered["message"] != unknown["message"] {
+		t.Fatalf("responses differ: %v and %v", registered, unknown)
+	}
+	app.Resets.Wait()
+
+	app.Mail.SetError(nil)
+	forgot(alice.Email)
+	app.Resets.Wait()
+	token, ok := app.Mail.LinkToken(alice.Email)
+	if !ok {
+		t.Fatal("no reset link was sent")
+	}
+	app.Do(testutil.Request{Method: http.MethodPost, Path: "/api/v1/auth/password/reset", Body: map[string]string{
+		"token":    token,
+		"password": "a new password",
+	}}).Expect(http.StatusOK)
+	app.Login(alice.Email, "a new password")
+}
+
+func TestFailedLoginsLookTheSameForUnknownEmails(t *testing.T) {
+	app := testutil.NewApp(t)
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	support := app.SignUp("Sam Support", "sam@example.com")
+	app.SetRole(support.UserID, "support")
+
+	statuses := func(email string) []int {
+		codes := []int{}
+		for range 12 {
+			res := app.Do(testutil.Request{Method: http.MethodPost, Path: "/api/v1/login", Body: map[string]string{
+				"email":    email,
+				"password": "wrong password",
+			}})
+			codes = append(codes, res.Code)
+			// past the backoff delay, but well inside the failure window
+			app.Clock.Advance(time.Minute)
+		}
+		return codes
+	}
+
+	registered := statuses("alice@example.com")
+	unknown := statuses("nobody@example.com")
+	if !slices.Equal(registered, unknown) {
+		t.Fatalf("registered email got %v, unknown email got %v", registered, unknown)
+	}
+	if last := registered[len(registered)-1]; last != http.StatusLocked {
+		t.Fatalf("after 12 failures got %d, want 423", last)
+	}
+
+	support.Token = app.Login(support.Email, testutil.Password)
+	support.Post("/api/v1/admin/users/"+alice.UserID+"/unlock", nil).Expect(http.StatusOK)
+	app.Login(alice.Email, testutil.Password)
+}
+
+func TestWrongTwoFactorCodesLockLikePasswords(t *testing.T) {
+	app := testutil.NewApp(t)
+	alice := app.SignUp("Alice Example", "alice@example.com")
+
+	var enrolment struct {
+		Secret string `json:"secret"`
+	}
+	alice.Post("/api/v1/auth/2fa/enroll", nil).Expect(http.StatusOK).JSON(&enrolment)
+	code := func() string {
+		code, err := totp.CodeAt(enrolment.Secret, totp.Step(app.Clock.Now()))
+		if err != nil {
+			t.Fatalf("CodeAt: %v", err)
+		}
+		return code
+	}
+	alice.Post("/api/v1/auth/2fa/activate", map[string]string{"code": code()}).Expect(http.StatusOK)
+
+	// the tenth wrong code locks, so the eleventh is refused unchecked
+	var last int
+	for range 11 {
+		last = alice.Post("/api/v1/auth/2fa/disable", map[string]string{"code": "000000"}).Code
+		app.Clock.Advance(time.Minute)
+	}
+	if last != http.StatusLocked {
+		t.Fatalf("after 11 wrong codes got %d, want 423", last)
+	}
+
+	// the lock holds even for the right code, on the login challenge too
+	var login struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+	app.Do(testutil.Request{Method: http.MethodPost, Path: "/api/v1/login", Body: map[string]string{
+		"email":    alice.Email,
+		"password": testutil.Password,
+	}}).Expect(http.StatusOK).JSON(&login)
+	app.Do(testutil.Request{Method: http.MethodPost, Path: "/api/v1/auth/2fa/verify", Body: map[string]string{
+		"challenge_token": login.ChallengeToken,
+		"code":            code(),
+	}}).Expect(http.StatusLocked)
+}
+
+func TestTransferFailsWhenItCannotBeAudited(t *testing.T) {
+	app := testutil.NewApp(t)
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	bob := app.SignUp("Bob Example", "bob@example.com")
+	from := alice.OpenAccount("USD")
+	to := bob.OpenAccount("USD")
+	app.Fund(from, "50.00", "USD")
+
+	app.AuditLog.SetError(errors.New("audit store down"))
+	alice.Transfer(from, to, "10.00", "USD").Expect(http.StatusInternalServerError)
+
+	app.AuditLog.SetError(nil)
+	alice.Transfer(from, to, "10.00", "USD").Expect(http.StatusCreated)
+}
+
+func TestReadinessHidesCheckErrorsFromAnonymousCallers(t *testing.T) {
+	app := testutil.NewApp(t)
+	app.Rates.SetError(errors.New("GET https://rates.internal:8443/latest: connection refused"))
+	app.Clock.Advance(48 * time.Hour)
+
+	anonymous := app.Do(testutil.Request{Method: http.MethodGet, Path: "/readyz"}).Expect(http.StatusOK)
+	var report struct {
+		Status string           `json:"status"`
+		Checks []map[string]any `json:"checks"`
+	}
+	anonymous.JSON(&report)
+	if report.Status != "degraded" {
+		t.Fatalf("status = %q, want degraded", report.Status)
+	}
+	for _, check := range report.Checks {
+		if len(check) != 2 || check["name"] == nil || check["status"] == nil {
+			t.Fatalf("anonymous caller got %v, want only name and status", check)
+		}
+	}
+
+	operator := app.Do(testutil.Request{Method: http.MethodGet, Path: "/readyz", Headers: map[string]string{
+		"Authorization": "Bearer " + testutil.MetricsToken,
+	}}).Expect(http.StatusOK)
+	var detailed struct {
+		Checks []map[string]any `json:"checks"`
+	}
+	operator.JSON(&detailed)
+	for _, check := range detailed.Checks {
+		if check["name"] == "rates" && check["error"] != nil {
+			return
+		}
+	}
+	t.Fatalf("operator got %v, want the rates error", detailed.Checks)
+}
+
+func TestStaffChangesAreRecordedOnceAndNotWithoutARecord(t *testing.T) {
+	app := testutil.NewApp(t)
+	alice := app.SignUp("Alice Example", "alice@example.com")
+	account := alice.OpenAccount("USD")
+	admin := app.SignUp("Ada Admin", "ada@example.com")
+	app.SetRole(admin.UserID, "admin")
+
+	admin.Post("/api/v1/admin/accounts/"+account+"/freeze", map[string]string{"reason": "chargeback"}).Expect(http.StatusOK)
+
+	var log struct {
+		Actions []struct {
+			Action string `json:"action"`
+			Status int    `json:"status"`
+		} `json:"actions"`
+	}
+	admin.Get("/api/v1/admin/actions").Expect(http.StatusOK).JSON(&log)
+	freezes := 0
+	for _, action := range log.Actions {
+		if action.Action == "accounts.freeze" {
+			freezes++
+			if action.Status != http.StatusOK {
+				t.Errorf("freeze recorded with status %d, want 200", action.Status)
+			}
+		}
+	}
+	if freezes != 1 {
+		t.Fatalf("freeze recorded %d times, want once", freezes)
+	}
+
+	app.AuditLog.SetError(errors.New("audit store down"))
+	admin.Post("/api/v1/admin/accounts/"+account+"/unfreeze", nil).Expect(http.StatusInternalServerError)
+}
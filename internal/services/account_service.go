@@ -1 +1,98 @@
 package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+type AccountService struct {
+	AccountRepo repositories.AccountRepository
+	Ledger      ledger.Ledger
+	Limits      *LimitService
+	Clock       clock.Clock
+}
+
+func NewAccountService(repo repositories.AccountRepository, ledger ledger.Ledger, limits *LimitService) *AccountService {
+	return &AccountService{
+		AccountRepo: repo,
+		Ledger:      ledger,
+		Limits:      limits,
+		Clock:       clock.System,
+	}
+}
+
+// CreateAccount opens a new wallet account for the user in the given ISO 4217 currency
+func (s *AccountService) CreateAccount(ctx context.Context, userID, currency string) (*models.Account, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	currency = money.NormalizeCurrency(currency)
+	if !money.IsSupported(currency) {
+		return nil, ErrUnsupportedCurrency
+	}
+	if err := s.Limits.CheckAccountOpen(userID, currency); err != nil {
+		return nil, err
+	}
+
+	account := models.Account{
+		UserID:   ownerID,
+		Currency: currency,
+		Type:     models.AccountTypeWallet,
+		Balance:  money.Zero(currency),
+		IsActive: true,
+	}
+
+	return s.AccountRepo.CreateAccount(ctx, &account)
+}
+
+func (s *AccountService) ListAccounts(ctx context.Context, userID string) ([]models.Account, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.AccountRepo.ListByUser(ctx, ownerID)
+}
+
+// GetAccount returns the account only if it belongs to the user; other users' accounts look missing
+func (s *AccountService) GetAccount(ctx context.Context, userID, accountID string) (*models.Account, error) {
+	account, err := s.AccountRepo.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.UserID.Hex() != userID {
+		return nil, repositories.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// BalanceAt returns the ledger-derived balance of a user's account at a point in time,
+// together with a reconciliation of the current stored balance against the postings.
+func (s *AccountService) BalanceAt(ctx context.Context, userID, accountID string, at time.Time) (money.Money, *ledger.Reconciliation, error) {
+	account, err := s.GetAccount(ctx, userID, accountID)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+
+	balance, err := s.Ledger.BalanceAt(ctx, account.ID, at)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+
+	reconciliation, err := s.Ledger.Reconcile(ctx, account.ID)
+	if err != nil {
+		return money.Money{}, nil, err
+	}
+	return balance, reconciliation, nil
+}
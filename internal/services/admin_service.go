@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidRole   = errors.New("unknown role")
+	ErrOwnRoleChange = errors.New("staff cannot change their own role")
+)
+
+var validRoles = map[string]bool{
+	models.RoleUser:       true,
+	models.RoleSupport:    true,
+	models.RoleCompliance: true,
+	models.RoleAdmin:      true,
+}
+
+// AdminService backs the staff back office: looking up customers and their money,
+// changing roles and freezing accounts.
+type AdminService struct {
+	UserRepo        repositories.UserRepository
+	AccountRepo     repositories.AccountRepository
+	TransactionRepo repositories.TransactionRepository
+	ActionRepo      repositories.AdminActionRepository
+	KYC             *KYCService
+	Transactor      repositories.Transactor
+	Audit           *audit.Logger
+}
+
+func NewAdminService(
+	userRepo repositories.UserRepository,
+	accountRepo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	actionRepo repositories.AdminActionRepository,
+	kycService *KYCService,
+	transactor repositories.Transactor,
+	auditLog *audit.Logger,
+) *AdminService {
+	return &AdminService{
+		UserRepo:        userRepo,
+		AccountRepo:     accountRepo,
+		TransactionRepo: txRepo,
+		ActionRepo:      actionRepo,
+		KYC:             kycService,
+		Transactor:      transactor,
+		Audit:           auditLog,
+	}
+}
+
+func (s *AdminService) SearchUsers(filter repositories.UserFilter, page, limit int) ([]models.User, error) {
+	return s.UserRepo.SearchUsers(filter, page, limit)
+}
+
+// GetUser returns a user together with all of their accounts.
+func (s *AdminService) GetUser(ctx context.Context, userID string) (*models.User, []models.Account, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	accounts, err := s.AccountRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, accounts, nil
+}
+
+// SetRole grants role to a user. Staff may not change their own role, so the last admin
+// cannot lock everyone out by accident.
+func (s *AdminService) SetRole(ctx context.Context, action *models.AdminAction, userID, role string) (*models.User, error) {
+	if !validRoles[role] {
+		return nil, ErrInvalidRole
+	}
+	if action.StaffID.Hex() == userID {
+		return nil, ErrOwnRoleChange
+	}
+	if _, err := primitive.ObjectIDFromHex(userID); err != nil {
+		return nil, repositories.ErrUserNotFound
+	}
+	err := s.withAction(ctx, action, func(ctx context.Context) error {
+		return s.UserRepo.SetRole(ctx, userID, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.UserRepo.FindByID(userID)
+}
+
+// BootstrapAdmin promotes the user with email to admin so a fresh deployment has someone
+// who can grant roles. It only ever does so while there is no admin at all, and only for
+// a user who has verified the address, so whoever registers it first cannot take over.
+// Anything else is logged rather than returned: the user may not have registered yet.
+func (s *AdminService) BootstrapAdmin(ctx context.Context, email string) error {
+	admins, err := s.UserRepo.SearchUsers(repositories.UserFilter{Role: models.RoleAdmin}, 1, 1)
+	if err != nil {
+		return err
+	}
+	if len(admins) > 0 {
+		slog.Warn("An admin already exists, ignoring BOOTSTRAP_ADMIN_EMAIL; unset it", "email", email)
+		return nil
+	}
+
+	user, err := s.UserRepo.FindByEmail(email)
+	if err != nil {
+		slog.Warn("Bootstrap admin not found, register the user and restart", "email", email)
+		return nil
+	}
+	if !user.EmailVerified {
+		slog.Error("Refusing to promote bootstrap admin with an unverified email, verify it and restart", "email", email)
+		return nil
+	}
+
+	err = s.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.UserRepo.SetRole(ctx, user.ID.Hex(), models.RoleAdmin); err != nil {
+			return err
+		}
+		return s.Audit.Append(ctx, &audit.Event{
+			Type:        audit.EventAdminAction,
+			ActorID:     audit.ActorSystem,
+			SubjectType: audit.SubjectUser,
+			SubjectID:   user.ID.Hex(),
+			Data:        map[string]string{"action": "users.bootstrap_admin", "role": models.RoleAdmin},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	slog.Warn("Promoted bootstrap user to admin, unset BOOTSTRAP_ADMIN_EMAIL", "email", email)
+	return nil
+}
+
+func (s *AdminService) FreezeAccount(ctx context.Context, action *models.AdminAction, accountID, reason string) (*models.Account, error) {
+	id, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, repositories.ErrAccountNotFound
+	}
+	var account *models.Account
+	err = s.withAction(ctx, action, func(ctx context.Context) error {
+		account, err = s.AccountRepo.SetFrozen(ctx, id, true, action.StaffID, reason)
+		return err
+	})
+	return account, err
+}
+
+func (s *AdminService) UnfreezeAccount(ctx context.Context, action *models.AdminAction, accountID string) (*models.Account, error) {
+	id, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		return nil, repositories.ErrAccountNotFound
+	}
+	var account *models.Account
+	err = s.withAction(ctx, action, func(ctx context.Context) error {
+		account, err = s.AccountRepo.SetFrozen(ctx, id, false, primitive.NilObjectID, "")
+		return err
+	})
+	return account, err
+}
+
+// DecideKYCCase applies a reviewer's decision on a submitted case, see KYCService.Review.
+func (s *AdminService) DecideKYCCase(ctx context.Context, action *models.AdminAction, caseID, status, reason string) (*models.KYCCase, error) {
+	kycCase, err := s.KYC.Review(ctx, action.StaffID.Hex(), caseID, status, reason, func(ctx context.Context) error {
+		return s.appendAction(ctx, action)
+	})
+	if err != nil {
+		action.ID = primitive.NilObjectID
+	}
+	return kycCase, err
+}
+
+// withAction makes change and stores the staff action describing it in one transaction,
+// so a change never lands without its record. When either fails the action is left
+// unstored, for the audit middleware to record as a failed attempt.
+func (s *AdminService) withAction(ctx context.Context, action *models.AdminAction, change func(ctx context.Context) error) error {
+	err := s.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := change(ctx); err != nil {
+			return err
+		}
+		return s.appendAction(ctx, action)
+	})
+	if err != nil {
+		action.ID = primitive.NilObjectID
+	}
+	return err
+}
+
+// ListTransactions narrows to one account, else to every account of one user, else
+// returns the most recent transactions across the wallet.
+func (s *AdminService) ListTransactions(ctx context.Context, userID, accountID string, page, limit int) ([]models.Transaction, error) {
+	switch {
+	case accountID != "":
+		id, err := primitive.ObjectIDFromHex(accountID)
+		if err != nil {
+			return nil, repositories.ErrAccountNotFound
+		}
+		return s.TransactionRepo.ListByAccounts(ctx, []primitive.ObjectID{id}, page, limit)
+	case userID != "":
+		ownerID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return nil, errors.New("invalid user ID")
+		}
+		accounts, err := s.AccountRepo.ListByUser(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]primitive.ObjectID, 0, len(accounts))
+		for _, account := range accounts {
+			ids = append(ids, account.ID)
+		}
+		return s.TransactionRepo.ListByAccounts(ctx, ids, page, limit)
+	}
+	return s.TransactionRepo.ListRecent(ctx, page, limit)
+}
+
+func (s *AdminService) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	return s.TransactionRepo.FindByID(ctx, id)
+}
+
+// Record stores a staff action that changed nothing, such as a lookup or a refused
+// request, once it has been answered. Failures are logged rather than returned because by
+// then the response has been sent. Changes are stored with withAction instead.
+func (s *AdminService) Record(ctx context.Context, action *models.AdminAction) {
+	if err := s.appendAction(ctx, action); err != nil {
+		slog.ErrorContext(ctx, "Failed to record admin action", "action", action.Action, "staff_id", action.StaffID.Hex(), "error", err)
+	}
+}
+
+// appendAction stores action in the searchable action log and in the audit chain, the
+// audit event last as the in-memory transactor cannot roll back earlier writes.
+func (s *AdminService) appendAction(ctx context.Context, action *models.AdminAction) error {
+	if err := s.ActionRepo.Create(ctx, action); err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"action": action.Action,
+		"role":   action.StaffRole,
+		"method": action.Method,
+		"path":   action.Path,
+		"status": fmt.Sprint(action.Status),
+	}
+	for key, value := range action.Details {
+		data["detail_"+key] = fmt.Sprint(value)
+	}
+	return s.Audit.Append(ctx, &audit.Event{
+		Type:        audit.EventAdminAction,
+		ActorID:     action.StaffID.Hex(),
+		SubjectType: action.TargetType,
+		SubjectID:   action.TargetID,
+		IP:          action.IP,
+		Data:        data,
+	})
+}
+
+// VerifyAuditLog checks the whole audit chain for tampering.
+func (s *AdminService) VerifyAuditLog(ctx context.Context) (*audit.VerifyResult, error) {
+	return s.Audit.Verify(ctx)
+}
+
+// ListActions returns the staff action log, optionally for one staff member only.
+func (s *AdminService) ListActions(ctx context.Context, staffID string, page, limit int) ([]models.AdminAction, error) {
+	if staffID == "" {
+		return s.ActionRepo.List(ctx, nil, page, limit)
+	}
+	id, err := primitive.ObjectIDFromHex(staffID)
+	if err != nil {
+		return nil, errors.New("invalid staff ID")
+	}
+	return s.ActionRepo.List(ctx, &id, page, limit)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories/memory"
+)
+
+func TestBootstrapAdminNeedsAVerifiedEmailAndNoAdmin(t *testing.T) {
+	ctx := context.Background()
+	users := memory.NewUserRepo(clock.System)
+	service := NewAdminService(users, nil, nil, nil, nil, memory.NewTransactor(), audit.NewLogger(audit.NewMemoryStore()))
+
+	register := func(email string) *models.User {
+		user, err := users.CreateUser(&models.User{FullName: email, Email: email})
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		return user
+	}
+	roleOf := func(user *models.User) string {
+		found, err := users.FindByID(user.ID.Hex())
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		return found.Role
+	}
+	bootstrap := func(email string) {
+		if err := service.BootstrapAdmin(ctx, email); err != nil {
+			t.Fatalf("BootstrapAdmin(%s): %v", email, err)
+		}
+	}
+
+	first := register("first@example.com")
+	bootstrap("first@example.com")
+	if role := roleOf(first); role != models.RoleUser {
+		t.Fatalf("unverified user promoted to %s", role)
+	}
+
+	if _, err := users.MarkEmailVerified(first.ID.Hex(), first.Email); err != nil {
+		t.Fatalf("MarkEmailVerified: %v", err)
+	}
+	bootstrap("first@example.com")
+	if role := roleOf(first); role != models.RoleAdmin {
+		t.Fatalf("verified user has role %s, want admin", role)
+	}
+
+	// a later deployment pointing the variable elsewhere must not mint a second admin
+	second := register("second@example.com")
+	if _, err := users.MarkEmailVerified(second.ID.Hex(), second.Email); err != nil {
+		t.Fatalf("MarkEmailVerified: %v", err)
+	}
+	bootstrap("second@example.com")
+	if role := roleOf(second); role != models.RoleUser {
+		t.Fatalf("second bootstrap user promoted to %s while an admin exists", role)
+	}
+}
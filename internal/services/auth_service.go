@@ -1,34 +1,167 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
 	"github.com/samoray1998/fintech-wallet/internal/models"
 	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	tokenTypeAccess    = "access"
+	tokenTypeChallenge = "2fa_challenge"
+	tokenTypeEmail     = "email_verify"
+)
+
+var (
+	ErrInvalidChallenge    = errors.New("invalid or expired two-factor challenge")
+	ErrInvalidVerification = errors.New("invalid or expired verification link")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+)
+
+// TokenPair is what a client receives after logging in or refreshing.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// AccessClaims are the fields of a validated access token the rest of the app relies on.
+type AccessClaims struct {
+	UserID    string
+	Email     string
+	TokenID   string
+	SessionID string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
 type AuthService struct {
-	UserRepo     repositories.UserRepository
-	JWT_SECRET   string
-	AccessExpiry time.Duration
+	UserRepo        repositories.UserRepository
+	TokenRepo       repositories.TokenRepository
+	Revocations     *RevocationService
+	JWT_SECRET      string
+	AccessExpiry    time.Duration
+	RefreshExpiry   time.Duration
+	ChallengeExpiry time.Duration
+	Clock           clock.Clock
 }
 
-func NewAuthService(repo repositories.UserRepository, jwtSecret string, accessExpiry time.Duration) *AuthService {
+func NewAuthService(repo repositories.UserRepository, tokenRepo repositories.TokenRepository, revocations *RevocationService, jwtSecret string, accessExpiry, refreshExpiry, challengeExpiry time.Duration) *AuthService {
 	return &AuthService{
-		UserRepo:     repo,
-		JWT_SECRET:   jwtSecret,
-		AccessExpiry: accessExpiry,
+		UserRepo:        repo,
+		TokenRepo:       tokenRepo,
+		Revocations:     revocations,
+		JWT_SECRET:      jwtSecret,
+		AccessExpiry:    accessExpiry,
+		RefreshExpiry:   refreshExpiry,
+		ChallengeExpiry: challengeExpiry,
+		Clock:           clock.System,
+	}
+}
+
+// GenerateTokens starts a new session: an access token plus the first refresh token of a new family.
+func (s *AuthService) GenerateTokens(ctx context.Context, user *models.User) (*TokenPair, error) {
+	return s.issuePair(ctx, user, primitive.NewObjectID(), nil)
+}
+
+// Refresh rotates a refresh token. Presenting a token that has already been rotated
+// revokes its whole family, logging out both the thief and the legitimate client.
+func (s *AuthService) Refresh(ctx context.Context, rawToken string) (*TokenPair, error) {
+	current, err := s.TokenRepo.FindByHash(ctx, hashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, repositories.ErrTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
 	}
+
+	now := s.Clock.Now()
+	if current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.RotatedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, current.FamilyID)
+	}
+
+	user, err := s.UserRepo.FindByID(current.UserID.Hex())
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issuePair(ctx, user, current.FamilyID, current)
 }
 
-func (s *AuthService) GenerateTokens(user *models.User) (string, error) {
+func (s *AuthService) revokeReusedFamily(ctx context.Context, familyID primitive.ObjectID) error {
+	if err := s.TokenRepo.RevokeFamily(ctx, familyID, s.Clock.Now()); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (s *AuthService) issuePair(ctx context.Context, user *models.User, familyID primitive.ObjectID, previous *models.RefreshToken) (*TokenPair, error) {
+	rawRefresh, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	next := &models.RefreshToken{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawRefresh),
+		ExpiresAt: s.Clock.Now().Add(s.RefreshExpiry),
+	}
+
+	if previous != nil {
+		err := s.TokenRepo.MarkRotated(ctx, previous.ID, next.ID, s.Clock.Now())
+		if errors.Is(err, repositories.ErrTokenAlreadyRotated) {
+			// someone else rotated this token first: treat it as reuse
+			return nil, s.revokeReusedFamily(ctx, familyID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.TokenRepo.Create(ctx, next); err != nil {
+		return nil, err
+	}
 
+	accessToken, err := s.signAccessToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefresh,
+		ExpiresIn:    s.AccessExpiry,
+	}, nil
+}
+
+func (s *AuthService) signAccessToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	now := s.Clock.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(s.AccessExpiry).Unix(),
+		"typ":     tokenTypeAccess,
+		"jti":     primitive.NewObjectID().Hex(),
+		"sid":     sessionID.Hex(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.AccessExpiry).Unix(),
 		"kyc":     user.KYCStatus,
 	}
 
@@ -36,8 +169,14 @@ func (s *AuthService) GenerateTokens(user *models.User) (string, error) {
 	return token.SignedString([]byte(s.JWT_SECRET))
 }
 
+// ValidateToken checks the signature, then exp, iat and nbf against the service clock
+// rather than the jwt package's own.
 func (s *AuthService) ValidateToken(tokenStr string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
 		return []byte(s.JWT_SECRET), nil
 	})
 
@@ -45,8 +184,185 @@ func (s *AuthService) ValidateToken(tokenStr string) (jwt.MapClaims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	now := s.Clock.Now().Unix()
+	if !claims.VerifyExpiresAt(now, false) {
+		return nil, errors.New("token is expired")
+	}
+	if !claims.VerifyIssuedAt(now, false) || !claims.VerifyNotBefore(now, false) {
+		return nil, errors.New("token used before issued")
+	}
+	return claims, nil
+}
+
+// Authenticate validates an access token and makes sure it has not been revoked.
+func (s *AuthService) Authenticate(ctx context.Context, tokenStr string) (*AccessClaims, error) {
+	claims, err := s.ValidateToken(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := parseAccessClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.Revocations.IsRevoked(ctx, access.TokenID, access.SessionID, access.UserID, access.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return access, nil
+}
+
+// Logout ends the session the access token belongs to: the token itself, every other
+// access token of the session, and the session's refresh token family.
+func (s *AuthService) Logout(ctx context.Context, claims *AccessClaims) error {
+	if err := s.Revocations.Revoke(ctx, tokenKey(claims.TokenID), claims.ExpiresAt); err != nil {
+		return err
 	}
-	return nil, errors.New("invalid token")
+	if err := s.Revocations.Revoke(ctx, sessionKey(claims.SessionID), s.Clock.Now().Add(s.RefreshExpiry)); err != nil {
+		return err
+	}
+
+	familyID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		return nil
+	}
+	return s.TokenRepo.RevokeFamily(ctx, familyID, s.Clock.Now())
+}
+
+// LogoutAll invalidates every access and refresh token issued to the user so far.
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	if err := s.Revocations.Revoke(ctx, userKey(userID), s.Clock.Now().Add(s.AccessExpiry)); err != nil {
+		return err
+	}
+	return s.TokenRepo.RevokeAllForUser(ctx, ownerID, s.Clock.Now())
+}
+
+// IssueChallenge hands out the short-lived token a 2FA user trades, together with a
+// valid code, for real tokens. It cannot be used as an access token.
+func (s *AuthService) IssueChallenge(user *models.User) (string, error) {
+	now := s.Clock.Now()
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"typ":     tokenTypeChallenge,
+		"jti":     primitive.NewObjectID().Hex(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.ChallengeExpiry).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.JWT_SECRET))
+}
+
+// ConsumeChallenge validates a challenge token and burns it so it works only once.
+func (s *AuthService) ConsumeChallenge(ctx context.Context, tokenStr string) (*models.User, error) {
+	claims, err := s.ValidateToken(tokenStr)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	typ, _ := claims["typ"].(string)
+	userID, _ := claims["user_id"].(string)
+	jti, _ := claims["jti"].(string)
+	iat, _ := claims["iat"].(float64)
+	exp, _ := claims["exp"].(float64)
+	if typ != tokenTypeChallenge || userID == "" || jti == "" {
+		return nil, ErrInvalidChallenge
+	}
+
+	revoked, err := s.Revocations.IsRevoked(ctx, jti, "", userID, time.Unix(int64(iat), 0))
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidChallenge
+	}
+	if err := s.Revocations.Revoke(ctx, tokenKey(jti), time.Unix(int64(exp), 0)); err != nil {
+		return nil, err
+	}
+
+	return s.UserRepo.FindByID(userID)
+}
+
+// SignEmailVerification returns a token proving the holder received mail at the user's
+// current address. It is bound to that address and expires after expiry.
+func (s *AuthService) SignEmailVerification(user *models.User, expiry time.Duration) (string, error) {
+	now := s.Clock.Now()
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"typ":     tokenTypeEmail,
+		"iat":     now.Unix(),
+		"exp":     now.Add(expiry).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.JWT_SECRET))
+}
+
+// ParseEmailVerification returns the user ID and address an email verification token was issued for.
+func (s *AuthService) ParseEmailVerification(tokenStr string) (string, string, error) {
+	claims, err := s.ValidateToken(tokenStr)
+	if err != nil {
+		return "", "", ErrInvalidVerification
+	}
+
+	typ, _ := claims["typ"].(string)
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	if typ != tokenTypeEmail || userID == "" || email == "" {
+		return "", "", ErrInvalidVerification
+	}
+	return userID, email, nil
+}
+
+func parseAccessClaims(claims jwt.MapClaims) (*AccessClaims, error) {
+	if typ, _ := claims["typ"].(string); typ != tokenTypeAccess {
+		return nil, errors.New("invalid token")
+	}
+
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	iat, _ := claims["iat"].(float64)
+	exp, _ := claims["exp"].(float64)
+
+	if userID == "" || jti == "" || sid == "" {
+		return nil, errors.New("invalid token")
+	}
+
+	return &AccessClaims{
+		UserID:    userID,
+		Email:     email,
+		TokenID:   jti,
+		SessionID: sid,
+		IssuedAt:  time.Unix(int64(iat), 0),
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories/memory"
+)
+
+func newTestAuthService(t *testing.T, refreshExpiry time.Duration) (*AuthService, *models.User) {
+	t.Helper()
+	users := memory.NewUserRepo(clock.System)
+	user, err := users.CreateUser(&models.User{FullName: "Ada", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	s := NewAuthService(users, memory.NewTokenRepo(clock.System), nil, "test-secret", 15*time.Minute, refreshExpiry, 5*time.Minute)
+	return s, user
+}
+
+func TestAccessTokenCarriesSession(t *testing.T) {
+	s, user := newTestAuthService(t, time.Hour)
+
+	pair, err := s.GenerateTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	claims, err := s.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	access, err := parseAccessClaims(claims)
+	if err != nil {
+		t.Fatalf("parseAccessClaims: %v", err)
+	}
+	if access.UserID != user.ID.Hex() || access.SessionID == "" || access.TokenID == "" {
+		t.Fatalf("unexpected claims %+v", access)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	s, user := newTestAuthService(t, time.Hour)
+	ctx := context.Background()
+
+	first, err := s.GenerateTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("Refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s, user := newTestAuthService(t, time.Hour)
+	ctx := context.Background()
+
+	first, err := s.GenerateTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	if _, err := s.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replayed token: got %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("token of the revoked family: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshRejectsExpiredAndUnknownTokens(t *testing.T) {
+	s, user := newTestAuthService(t, -time.Second)
+	ctx := context.Background()
+
+	pair, err := s.GenerateTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	if _, err := s.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expired token: got %v", err)
+	}
+	if _, err := s.Refresh(ctx, "not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: got %v", err)
+	}
+}
+
+func TestLoginRightAfterLogoutAll(t *testing.T) {
+	s, user := newTestAuthService(t, time.Hour)
+	clk := clock.NewFake(time.Date(2025, 3, 3, 9, 0, 0, 100*int(time.Millisecond), time.UTC))
+	s.Clock = clk
+	s.Revocations = NewRevocationService(memory.NewRevocationRepo(), 5*time.Second)
+	s.Revocations.Clock = clk
+	ctx := context.Background()
+
+	earlier, err := s.GenerateTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	clk.Advance(time.Second)
+	if err := s.LogoutAll(ctx, user.ID.Hex()); err != nil {
+		t.Fatalf("LogoutAll: %v", err)
+	}
+	clk.Advance(300 * time.Millisecond) // still the same second as the logout
+	again, err := s.GenerateTokens(ctx, user)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	if _, err := s.Authenticate(ctx, earlier.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("token from before logout-all: got %v, want ErrTokenRevoked", err)
+	}
+	if _, err := s.Authenticate(ctx, again.AccessToken); err != nil {
+		t.Fatalf("token issued in the second of logout-all: %v", err)
+	}
+}
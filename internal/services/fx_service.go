@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FXPoolAccount is the system account, one per currency, that sits on the other side of every conversion.
+const FXPoolAccount = "fx_pool"
+
+var (
+	ErrSameCurrency   = &TransferError{Code: "same_currency", Message: "accounts hold the same currency, use a transfer instead"}
+	ErrQuoteNotFound  = &TransferError{Code: "quote_not_found", Message: "quote not found"}
+	ErrQuoteExpired   = &TransferError{Code: "quote_expired", Message: "quote has expired"}
+	ErrQuoteUsed      = &TransferError{Code: "quote_used", Message: "quote has already been used"}
+	ErrAmountTooSmall = &TransferError{Code: "amount_too_small", Message: "amount converts to zero in the target currency"}
+)
+
+type FXService struct {
+	QuoteRepo       repositories.FXQuoteRepository
+	AccountRepo     repositories.AccountRepository
+	TransactionRepo repositories.TransactionRepository
+	RateService     *RateService
+	Ledger          ledger.Ledger
+	Transactor      repositories.Transactor
+	Limits          *LimitService
+	Audit           *audit.Logger
+	Metrics         *metrics.Metrics
+	Clock           clock.Clock
+	spreadBps       int
+	quoteTTL        time.Duration
+	maxRateAge      time.Duration
+}
+
+func NewFXService(
+	quoteRepo repositories.FXQuoteRepository,
+	accountRepo repositories.AccountRepository,
+	txRepo repositories.TransactionRepository,
+	rateService *RateService,
+	walletLedger ledger.Ledger,
+	transactor repositories.Transactor,
+	limits *LimitService,
+	auditLog *audit.Logger,
+	walletMetrics *metrics.Metrics,
+	spreadBps int,
+	quoteTTL time.Duration,
+	maxRateAge time.Duration,
+) *FXService {
+	return &FXService{
+		QuoteRepo:       quoteRepo,
+		AccountRepo:     accountRepo,
+		TransactionRepo: txRepo,
+		RateService:     rateService,
+		Ledger:          walletLedger,
+		Transactor:      transactor,
+		Limits:          limits,
+		Audit:           auditLog,
+		Metrics:         walletMetrics,
+		Clock:           clock.System,
+		spreadBps:       spreadBps,
+		quoteTTL:        quoteTTL,
+		maxRateAge:      maxRateAge,
+	}
+}
+
+// CreateQuote prices selling amount out of one of the user's accounts into another,
+// applying the configured spread, and locks that price until the quote expires. It will
+// not price off rates older than maxRateAge, even while the rate service still serves them.
+func (s *FXService) CreateQuote(ctx context.Context, userID, fromAccountID, toAccountID string, amount money.Money) (*models.FXQuote, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	from, to, err := s.userAccounts(ctx, userID, fromAccountID, toAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Currency != from.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	midRate, snapshot, err := s.RateService.Rate(ctx, from.Currency, to.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if age := s.RateService.Age(snapshot); age > s.maxRateAge {
+		return nil, fmt.Errorf("%w: rates are %s old", ErrRatesUnavailable, age.Round(time.Second))
+	}
+
+	// customer rate = mid * (1 - spread), the difference stays in the FX pool
+	spread := big.NewRat(int64(10000-s.spreadBps), 10000)
+	rate := new(big.Rat).Mul(midRate, spread)
+
+	buy, err := amount.Convert(rate, to.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if !buy.IsPositive() {
+		return nil, ErrAmountTooSmall
+	}
+
+	quote := &models.FXQuote{
+		UserID:      from.UserID,
+		FromAccount: from.ID,
+		ToAccount:   to.ID,
+		Sell:        amount,
+		Buy:         buy,
+		MidRate:     midRate.FloatString(8),
+		Rate:        rate.FloatString(8),
+		SpreadBps:   s.spreadBps,
+		ExpiresAt:   s.Clock.Now().Add(s.quoteTTL),
+	}
+	return s.QuoteRepo.Create(ctx, quote)
+}
+
+// ExecuteConversion consumes the quote and moves the money across both accounts in a
+// single Mongo transaction, recording one linked transaction per leg and the audit event.
+func (s *FXService) ExecuteConversion(ctx context.Context, userID, quoteID string) ([]models.Transaction, error) {
+	quote, err := s.QuoteRepo.FindByID(ctx, quoteID)
+	if err != nil {
+		return nil, translateQuoteError(err)
+	}
+	if quote.UserID.Hex() != userID {
+		return nil, ErrQuoteNotFound
+	}
+
+	// create the pools up front: upserts do not belong inside the transaction
+	sellPool, err := s.Ledger.SystemAccount(ctx, FXPoolAccount, quote.Sell.Currency)
+	if err != nil {
+		return nil, err
+	}
+	buyPool, err := s.Ledger.SystemAccount(ctx, FXPoolAccount, quote.Buy.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var legs []models.Transaction
+	err = s.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		legs = nil
+
+		from, to, err := s.userAccounts(ctx, userID, quote.FromAccount.Hex(), quote.ToAccount.Hex())
+		if err != nil {
+			return err
+		}
+		if err := s.Limits.CheckConversion(ctx, from, to, quote.Sell, quote.Buy); err != nil {
+			return err
+		}
+
+		debit := models.Transaction{
+			ID:          primitive.NewObjectID(),
+			Type:        models.TransactionTypeFXConversion,
+			UserID:      from.UserID,
+			FromAccount: from.ID,
+			ToAccount:   sellPool.ID,
+			Amount:      quote.Sell,
+			Fee:         money.Zero(quote.Sell.Currency),
+			QuoteID:     quote.ID,
+			Status:      models.TransactionStatusCompleted,
+		}
+		credit := models.Transaction{
+			ID:          primitive.NewObjectID(),
+			Type:        models.TransactionTypeFXConversion,
+			UserID:      from.UserID,
+			FromAccount: buyPool.ID,
+			ToAccount:   to.ID,
+			Amount:      quote.Buy,
+			Fee:         money.Zero(quote.Buy.Currency),
+			QuoteID:     quote.ID,
+			Status:      models.TransactionStatusCompleted,
+		}
+		debit.LinkedID = credit.ID
+		credit.LinkedID = debit.ID
+
+		// MarkUsed updates the quote in place, so hand it a copy in case the transaction is retried
+		attempt := *quote
+		if err := s.QuoteRepo.MarkUsed(ctx, &attempt, debit.ID, s.Clock.Now()); err != nil {
+			return translateQuoteError(err)
+		}
+
+		sell, err := ledger.Transfer(from.ID, sellPool.ID, quote.Sell)
+		if err != nil {
+			return err
+		}
+		buy, err := ledger.Transfer(buyPool.ID, to.ID, quote.Buy)
+		if err != nil {
+			return err
+		}
+		entry, err := s.Ledger.Post(ctx, &ledger.JournalEntry{
+			Reference:   quote.ID.Hex(),
+			Description: "fx conversion",
+			Postings:    append(sell, buy...),
+		})
+		if err != nil {
+			return translateLedgerError(err)
+		}
+
+		for _, leg := range []*models.Transaction{&debit, &credit} {
+			leg.JournalEntryID = entry.ID
+			created, err := s.TransactionRepo.Create(ctx, leg)
+			if err != nil {
+				return err
+			}
+			legs = append(legs, *created)
+		}
+
+		// last, as the in-memory transactor cannot roll back earlier writes
+		return s.Audit.Append(ctx, &audit.Event{
+			Type:        audit.EventFXConversion,
+			ActorID:     userID,
+			SubjectType: audit.SubjectTransaction,
+			SubjectID:   legs[0].ID.Hex(),
+			Data: map[string]string{
+				"quote_id":     quote.ID.Hex(),
+				"from_account": quote.FromAccount.Hex(),
+				"to_account":   quote.ToAccount.Hex(),
+				"sell":         quote.Sell.String(),
+				"buy":          quote.Buy.String(),
+				"rate":         quote.Rate,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Metrics.FXConversion(quote.Sell.Currency, quote.Buy.Currency)
+	return legs, nil
+}
+
+func (s *FXService) userAccounts(ctx context.Context, userID, fromAccountID, toAccountID string) (*models.Account, *models.Account, error) {
+	from, err := s.AccountRepo.FindByID(ctx, fromAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := s.AccountRepo.FindByID(ctx, toAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from.UserID.Hex() != userID || to.UserID.Hex() != userID {
+		return nil, nil, repositories.ErrAccountNotFound
+	}
+	if !from.IsActive || !to.IsActive {
+		return nil, nil, ErrAccountInactive
+	}
+	if from.Currency == to.Currency {
+		return nil, nil, ErrSameCurrency
+	}
+	return from, to, nil
+}
+
+func translateQuoteError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrQuoteNotFound):
+		return ErrQuoteNotFound
+	case errors.Is(err, repositories.ErrQuoteExpired):
+		return ErrQuoteExpired
+	case errors.Is(err, repositories.ErrQuoteUsed):
+		return ErrQuoteUsed
+	}
+	return err
+}
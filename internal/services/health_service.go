@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	HealthOK       = "ok"
+	HealthFailing  = "failing"
+	HealthDegraded = "degraded"
+	HealthDraining = "draining"
+)
+
+// HealthCheck probes one dependency. A failing Critical check makes the instance
+// unready; other checks are reported but only mark it degraded, since pulling every
+// instance out of the load balancer would not bring back a shared third party.
+type HealthCheck struct {
+	Name     string
+	Timeout  time.Duration
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type HealthCheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type HealthReport struct {
+	Status string              `json:"status"`
+	Ready  bool                `json:"ready"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// HealthService runs the readiness checks and tracks whether the server is shutting down.
+// /readyz is public, so a report is reused for cacheFor and concurrent callers share one
+// run; hammering the endpoint cannot fan out to the database and third parties.
+type HealthService struct {
+	Clock    clock.Clock
+	checks   []HealthCheck
+	cacheFor time.Duration
+	draining atomic.Bool
+
+	run      singleflight.Group
+	mu       sync.Mutex
+	cached   *HealthReport
+	cachedAt time.Time
+}
+
+func NewHealthService(cacheFor time.Duration, checks ...HealthCheck) *HealthService {
+	return &HealthService{Clock: clock.System, checks: checks, cacheFor: cacheFor}
+}
+
+// Drain makes readiness fail from now on so load balancers stop routing new requests
+// here while in-flight ones finish.
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Readiness returns the cached report while it is fresh, otherwise runs every check.
+func (s *HealthService) Readiness(ctx context.Context) HealthReport {
+	if s.draining.Load() {
+		return HealthReport{Status: HealthDraining, Checks: []HealthCheckResult{}}
+	}
+
+	s.mu.Lock()
+	if s.cached != nil && s.Clock.Now().Sub(s.cachedAt) < s.cacheFor {
+		report := *s.cached
+		s.mu.Unlock()
+		return report
+	}
+	s.mu.Unlock()
+
+	// each check has its own timeout, so one caller going away does not cut the run short
+	report, _, _ := s.run.Do("readiness", func() (any, error) {
+		report := s.runChecks(context.WithoutCancel(ctx))
+		s.mu.Lock()
+		s.cached, s.cachedAt = &report, s.Clock.Now()
+		s.mu.Unlock()
+		return report, nil
+	})
+	return report.(HealthReport)
+}
+
+// runChecks runs every check concurrently, each under its own timeout, and logs the
+// failures since only authorised callers see their errors.
+func (s *HealthService) runChecks(ctx context.Context) HealthReport {
+	results := make([]HealthCheckResult, len(s.checks))
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runHealthCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: HealthOK, Ready: true, Checks: results}
+	for _, result := range results {
+		if result.Status == HealthOK {
+			continue
+		}
+		slog.WarnContext(ctx, "Readiness check failing", "check", result.Name, "critical", result.Critical, "error", result.Error)
+		if result.Critical {
+			report.Status = HealthFailing
+			report.Ready = false
+		} else if report.Ready {
+			report.Status = HealthDegraded
+		}
+	}
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := HealthCheckResult{
+		Name:     check.Name,
+		Status:   HealthOK,
+		Critical: check.Critical,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		result.Status = HealthFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// RatesFreshnessCheck refreshes the rate cache if it is due and fails when the newest
+// snapshot is older than maxAge, e.g. because the provider has been failing.
+func RatesFreshnessCheck(rateService *RateService, maxAge time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		snapshot, err := rateService.GetRates(ctx)
+		if err != nil {
+			return err
+		}
+		if age := rateService.Age(snapshot); age > maxAge {
+			return fmt.Errorf("rates are %s old", age.Round(time.Second))
+		}
+		return nil
+	}
+}
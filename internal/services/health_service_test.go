@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+)
+
+func TestReadinessReusesRecentReport(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC))
+	var calls atomic.Int32
+	s := NewHealthService(5*time.Second, HealthCheck{Name: "kyc_provider", Timeout: time.Second, Check: func(ctx context.Context) error {
+		calls.Add(1)
+		return errors.New("dial tcp 10.0.0.7:443: connection refused")
+	}})
+	s.Clock = clk
+	ctx := context.Background()
+
+	for range 10 {
+		if report := s.Readiness(ctx); report.Status != HealthDegraded {
+			t.Fatalf("status = %s, want %s", report.Status, HealthDegraded)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("check ran %d times within the cache period, want 1", got)
+	}
+
+	clk.Advance(5 * time.Second)
+	s.Readiness(ctx)
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("check ran %d times, want a second run once the report expired", got)
+	}
+
+	s.Drain()
+	if report := s.Readiness(ctx); report.Status != HealthDraining {
+		t.Fatalf("status while draining = %s, want the cache bypassed", report.Status)
+	}
+}
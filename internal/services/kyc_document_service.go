@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidDocumentType     = errors.New("unknown document type")
+	ErrUnsupportedDocumentMIME = errors.New("unsupported file type for this document")
+	ErrDocumentTooLarge        = errors.New("document is too large")
+	ErrDocumentStorageDisabled = errors.New("document storage is not configured")
+	ErrKYCDocumentsMissing     = errors.New("upload the required identity documents first")
+	ErrKYCDocumentNotFound     = errors.New("document not found")
+)
+
+// allowedDocumentTypes lists, per document, the content types accepted as detected from
+// the file itself; what the client claims is ignored.
+var allowedDocumentTypes = map[string][]string{
+	models.KYCDocumentIDFront:        {"image/jpeg", "image/png", "application/pdf"},
+	models.KYCDocumentIDBack:         {"image/jpeg", "image/png", "application/pdf"},
+	models.KYCDocumentSelfie:         {"image/jpeg", "image/png"},
+	models.KYCDocumentProofOfAddress: {"image/jpeg", "image/png", "application/pdf"},
+}
+
+// requiredDocumentTypes must be on a case before it can go to the provider. The back of
+// the ID is optional because passports have none.
+var requiredDocumentTypes = []string{models.KYCDocumentIDFront, models.KYCDocumentSelfie}
+
+type KYCDocumentService struct {
+	CaseRepo repositories.KYCCaseRepository
+	UserRepo repositories.UserRepository
+	Store    storage.BlobStore
+	maxBytes int64
+}
+
+func NewKYCDocumentService(caseRepo repositories.KYCCaseRepository, userRepo repositories.UserRepository, store storage.BlobStore, maxBytes int64) *KYCDocumentService {
+	return &KYCDocumentService{
+		CaseRepo: caseRepo,
+		UserRepo: userRepo,
+		Store:    store,
+		maxBytes: maxBytes,
+	}
+}
+
+// Upload validates a document and stores it on the user's open case, replacing an earlier
+// upload of the same type.
+func (s *KYCDocumentService) Upload(ctx context.Context, userID, docType string, file io.Reader) (*models.KYCDocument, error) {
+	if s.Store == nil {
+		return nil, ErrDocumentStorageDisabled
+	}
+	allowed, ok := allowedDocumentTypes[docType]
+	if !ok {
+		return nil, ErrInvalidDocumentType
+	}
+
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	switch user.KYCStatus {
+	case models.KYCStatusPending:
+		return nil, ErrKYCAlreadyPending
+	case models.KYCStatusVerified:
+		return nil, ErrKYCAlreadyVerified
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, s.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrDocumentTooLarge
+	}
+
+	contentType := mimetype.Detect(data).String()
+	if !mimetype.EqualsAny(contentType, allowed...) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDocumentMIME, contentType)
+	}
+
+	kycCase, err := s.CaseRepo.OpenCase(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	doc := models.KYCDocument{
+		ID:          primitive.NewObjectID(),
+		Type:        docType,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		UploadedAt:  time.Now(),
+	}
+	doc.BlobKey = fmt.Sprintf("kyc/%s/%s/%s", userID, kycCase.ID.Hex(), doc.ID.Hex())
+
+	if err := s.Store.Put(ctx, doc.BlobKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	replaced, err := s.CaseRepo.ReplaceDocument(ctx, kycCase.ID, doc)
+	if err != nil {
+		s.deleteBlob(ctx, doc.BlobKey)
+		return nil, err
+	}
+	if replaced != nil {
+		s.deleteBlob(ctx, replaced.BlobKey)
+	}
+	return &doc, nil
+}
+
+// CurrentCase returns the user's most recent case, open or not.
+func (s *KYCDocumentService) CurrentCase(ctx context.Context, userID string) (*models.KYCCase, error) {
+	ownerID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	return s.CaseRepo.FindLatest(ctx, ownerID)
+}
+
+// OpenDocument returns a document of a case together with its decrypted contents, for
+// review by staff. The caller must close the reader.
+func (s *KYCDocumentService) OpenDocument(ctx context.Context, caseID, documentID string) (*models.KYCDocument, io.ReadCloser, error) {
+	if s.Store == nil {
+		return nil, nil, ErrDocumentStorageDisabled
+	}
+
+	kycCase, err := s.CaseRepo.FindByID(ctx, caseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range kycCase.Documents {
+		doc := &kycCase.Documents[i]
+		if doc.ID.Hex() != documentID {
+			continue
+		}
+		body, err := s.Store.Get(ctx, doc.BlobKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return doc, body, nil
+	}
+	return nil, nil, ErrKYCDocumentNotFound
+}
+
+func (s *KYCDocumentService) deleteBlob(ctx context.Context, key string) {
+	if err := s.Store.Delete(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete KYC document blob", "key", key, "error", err)
+	}
+}
+
+// missingDocuments lists the required document types a case does not have yet.
+func missingDocuments(kycCase *models.KYCCase) []string {
+	missing := []string{}
+	for _, required := range requiredDocumentTypes {
+		found := false
+		for _, doc := range kycCase.Documents {
+			if doc.Type == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, required)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/models"
+)
+
+var ErrKYCProviderUnavailable = errors.New("identity verification provider unavailable")
+
+// KYCApplicant is what the provider needs to start checking a user's identity.
+type KYCApplicant struct {
+	UserID      string `json:"external_id"`
+	FullName    string `json:"full_name"`
+	Email       string `json:"email"`
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+	Country     string `json:"country"`       // ISO 3166-1 alpha-2
+	Address     string `json:"address,omitempty"`
+
+	CaseID    string                 `json:"case_id"`
+	Documents []KYCApplicantDocument `json:"documents"`
+}
+
+// KYCApplicantDocument describes an uploaded document. Only metadata is sent; the files
+// themselves stay in our blob store.
+type KYCApplicantDocument struct {
+	Type        string `json:"type"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+}
+
+// KYCSubmission is the provider's answer to a submission. Status is usually pending, with
+// the decision arriving later through the webhook, but providers may decide at once.
+type KYCSubmission struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// KYCProvider submits applicants to an identity verification service. Ping reports
+// whether the service can be reached at all, for the readiness probe.
+type KYCProvider interface {
+	Submit(ctx context.Context, applicant KYCApplicant) (*KYCSubmission, error)
+	Ping(ctx context.Context) error
+}
+
+// HTTPKYCProvider posts applicants to <url>/applicants, retrying network errors, 429s and
+// 5xx answers with exponential backoff. The external_id makes retries safe: the provider
+// returns the existing applicant instead of creating a second one.
+type HTTPKYCProvider struct {
+	URL        string
+	APIKey     string
+	MaxRetries int
+	Client     *http.Client
+	BaseDelay  time.Duration
+}
+
+func NewHTTPKYCProvider(apiURL, apiKey string, timeout time.Duration, maxRetries int) *HTTPKYCProvider {
+	return &HTTPKYCProvider{
+		URL:        strings.TrimSuffix(apiURL, "/"),
+		APIKey:     apiKey,
+		MaxRetries: maxRetries,
+		Client:     &http.Client{Timeout: timeout},
+		BaseDelay:  500 * time.Millisecond,
+	}
+}
+
+func (p *HTTPKYCProvider) Submit(ctx context.Context, applicant KYCApplicant) (*KYCSubmission, error) {
+	body, err := json.Marshal(applicant)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, p.retryDelay(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		submission, retryable, err := p.submitOnce(ctx, body)
+		if err == nil {
+			return submission, nil
+		}
+		if !retryable {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%w: %v", ErrKYCProviderUnavailable, lastErr)
+}
+
+func (p *HTTPKYCProvider) submitOnce(ctx context.Context, body []byte) (*KYCSubmission, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL+"/applicants", bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		// give up on our own cancellation, retry anything else at the transport level
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, true, fmt.Errorf("kyc provider returned %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, false, fmt.Errorf("kyc provider rejected submission: %s", resp.Status)
+	}
+
+	var submission KYCSubmission
+	if err := json.NewDecoder(resp.Body).Decode(&submission); err != nil {
+		return nil, false, err
+	}
+	if submission.Reference == "" {
+		return nil, false, errors.New("kyc provider returned no reference")
+	}
+	if submission.Status == "" {
+		submission.Status = models.KYCStatusPending
+	}
+	return &submission, false, nil
+}
+
+// Ping makes a single request to the provider's base URL. Any answer short of a 5xx
+// means the service is up; the path itself need not exist.
+func (p *HTTPKYCProvider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("kyc provider returned %s", resp.Status)
+	}
+	return nil
+}
+
+// retryDelay doubles BaseDelay per attempt with up to 50% jitter so clients that failed
+// together do not retry together.
+func (p *HTTPKYCProvider) retryDelay(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FakeKYCProvider accepts every applicant without calling anything, for local development
+// and tests. With Outcome set to verified or rejected it decides immediately; otherwise the
+// decision has to be posted to the webhook.
+type FakeKYCProvider struct {
+	Outcome string
+
+	mu          sync.Mutex
+	Submissions []KYCApplicant
+}
+
+func NewFakeKYCProvider(outcome string) *FakeKYCProvider {
+	return &FakeKYCProvider{Outcome: outcome}
+}
+
+func (p *FakeKYCProvider) Submit(ctx context.Context, applicant KYCApplicant) (*KYCSubmission, error) {
+	p.mu.Lock()
+	p.Submissions = append(p.Submissions, applicant)
+	p.mu.Unlock()
+
+	status := models.KYCStatusPending
+	if p.Outcome == models.KYCStatusVerified || p.Outcome == models.KYCStatusRejected {
+		status = p.Outcome
+	}
+	return &KYCSubmission{Reference: "fake-" + applicant.UserID, Status: status}, nil
+}
+
+func (p *FakeKYCProvider) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+const kycSignaturePrefix = "sha256="
+
+var (
+	ErrKYCAlreadyPending    = errors.New("identity verification is already in progress")
+	ErrKYCAlreadyVerified   = errors.New("identity is already verified")
+	ErrInvalidKYCSignature  = errors.New("invalid webhook signature")
+	ErrInvalidKYCEvent      = errors.New("invalid webhook payload")
+	ErrKYCWebhookDisabled   = errors.New("kyc webhook secret is not configured")
+	ErrKYCReferenceNotFound = errors.New("unknown kyc reference")
+	ErrKYCCaseNotSubmitted  = errors.New("kyc case is not awaiting a decision")
+	ErrInvalidKYCDecision   = errors.New("decision must be verified or rejected")
+)
+
+// KYCEvent is the decision the provider posts to the webhook.
+type KYCEvent struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"` // verified or rejected
+	Reason    string `json:"reason,omitempty"`
+}
+
+type KYCService struct {
+	UserRepo      repositories.UserRepository
+	CaseRepo      repositories.KYCCaseRepository
+	Provider      KYCProvider
+	Transactor    repositories.Transactor
+	Audit         *audit.Logger
+	Metrics       *metrics.Metrics
+	webhookSecret string
+}
+
+func NewKYCService(repo repositories.UserRepository, caseRepo repositories.KYCCaseRepository, provider KYCProvider, transactor repositories.Transactor, auditLog *audit.Logger, walletMetrics *metrics.Metrics, webhookSecret string) *KYCService {
+	return &KYCService{
+		UserRepo:      repo,
+		CaseRepo:      caseRepo,
+		Provider:      provider,
+		Transactor:    transactor,
+		Audit:         auditLog,
+		Metrics:       walletMetrics,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// Initiate submits the user's open case to the provider once it holds the required
+// documents. Unverified and previously rejected users may submit; anyone already pending
+// or verified may not.
+func (s *KYCService) Initiate(ctx context.Context, userID string, applicant KYCApplicant) (*models.User, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	switch user.KYCStatus {
+	case models.KYCStatusPending:
+		return nil, ErrKYCAlreadyPending
+	case models.KYCStatusVerified:
+		return nil, ErrKYCAlreadyVerified
+	}
+
+	kycCase, err := s.CaseRepo.FindOpenCase(ctx, user.ID)
+	if errors.Is(err, repositories.ErrKYCCaseNotFound) {
+		return nil, ErrKYCDocumentsMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingDocuments(kycCase); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKYCDocumentsMissing, strings.Join(missing, ", "))
+	}
+
+	applicant.UserID = userID
+	applicant.FullName = user.FullName
+	applicant.Email = user.Email
+	applicant.CaseID = kycCase.ID.Hex()
+	for _, doc := range kycCase.Documents {
+		applicant.Documents = append(applicant.Documents, KYCApplicantDocument{Type: doc.Type, ContentType: doc.ContentType, SHA256: doc.SHA256})
+	}
+
+	submission, err := s.Provider.Submit(ctx, applicant)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CaseRepo.MarkSubmitted(ctx, kycCase.ID, submission.Reference); err != nil {
+		return nil, err
+	}
+	if err := s.UserRepo.SetKYCSubmission(userID, submission.Reference, kycCase.ID); err != nil {
+		return nil, err
+	}
+	s.Metrics.KYCTransition(models.KYCStatusPending)
+	s.Audit.Record(ctx, *statusChange(userID, kycCase, models.KYCStatusPending, ""))
+
+	if submission.Status == models.KYCStatusVerified || submission.Status == models.KYCStatusRejected {
+		if _, err := s.applyDecision(ctx, audit.ActorSystem, submission.Reference, submission.Status, submission.Reason, nil); err != nil {
+			return nil, err
+		}
+	}
+	return s.UserRepo.FindByID(userID)
+}
+
+// HandleWebhook authenticates a provider callback and applies its decision. Repeated
+// deliveries of a decision that was already applied are accepted and ignored.
+func (s *KYCService) HandleWebhook(ctx context.Context, payload []byte, signature string) error {
+	if s.webhookSecret == "" {
+		return ErrKYCWebhookDisabled
+	}
+	if !s.validSignature(payload, signature) {
+		return ErrInvalidKYCSignature
+	}
+
+	var event KYCEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return ErrInvalidKYCEvent
+	}
+	if event.Reference == "" || (event.Status != models.KYCStatusVerified && event.Status != models.KYCStatusRejected) {
+		return ErrInvalidKYCEvent
+	}
+
+	applied, err := s.applyDecision(ctx, audit.ActorSystem, event.Reference, event.Status, event.Reason, nil)
+	if err != nil || applied {
+		return err
+	}
+
+	user, err := s.UserRepo.FindByKYCReference(event.Reference)
+	if err != nil {
+		return ErrKYCReferenceNotFound
+	}
+	if user.KYCStatus != event.Status {
+		slog.InfoContext(ctx, "Ignoring KYC decision for user already decided", "decision", event.Status, "reference", event.Reference, "kyc_status", user.KYCStatus)
+	}
+	return nil
+}
+
+// Review lets compliance staff decide a submitted case themselves instead of waiting for
+// the provider. A later webhook for the same case is then ignored. alongside runs in the
+// transaction that applies the decision, so the staff action can be stored with it.
+func (s *KYCService) Review(ctx context.Context, staffID, caseID, status, reason string, alongside func(ctx context.Context) error) (*models.KYCCase, error) {
+	if status != models.KYCStatusVerified && status != models.KYCStatusRejected {
+		return nil, ErrInvalidKYCDecision
+	}
+
+	kycCase, err := s.CaseRepo.FindByID(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if kycCase.Status != models.KYCCaseStatusSubmitted {
+		return nil, ErrKYCCaseNotSubmitted
+	}
+
+	applied, err := s.applyDecision(ctx, staffID, kycCase.KYCReference, status, reason, alongside)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		// the provider's decision landed first
+		return nil, ErrKYCCaseNotSubmitted
+	}
+	return s.CaseRepo.FindByID(ctx, caseID)
+}
+
+// applyDecision decides the pending user holding reference, closes their case and records
+// the change in one transaction, together with alongside when given. It reports false,
+// without running alongside, when no user is pending with that reference.
+func (s *KYCService) applyDecision(ctx context.Context, actorID, reference, status, reason string, alongside func(ctx context.Context) error) (bool, error) {
+	var applied bool
+	err := s.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		applied, err = s.UserRepo.ApplyKYCDecision(ctx, reference, status, reason)
+		if err != nil || !applied {
+			return err
+		}
+		if err := s.afterDecision(ctx, actorID, reference, status, reason); err != nil {
+			return err
+		}
+		if alongside != nil {
+			return alongside(ctx)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if applied {
+		s.Metrics.KYCTransition(status)
+	}
+	return applied, nil
+}
+
+// afterDecision closes the case and, on approval, raises the user to the tier its
+// documents support: proof of address on top of ID earns the higher tier.
+func (s *KYCService) afterDecision(ctx context.Context, actorID, reference, status, reason string) error {
+	kycCase, err := s.CaseRepo.FindByReference(ctx, reference)
+	if err != nil {
+		return err
+	}
+	if err := s.CaseRepo.MarkDecided(ctx, reference, status); err != nil {
+		return err
+	}
+
+	if status == models.KYCStatusVerified {
+		tier := models.KYCTierID
+		for _, doc := range kycCase.Documents {
+			if doc.Type == models.KYCDocumentProofOfAddress {
+				tier = models.KYCTierAddress
+			}
+		}
+		if err := s.UserRepo.SetKYCTier(ctx, kycCase.UserID.Hex(), tier); err != nil {
+			return err
+		}
+	}
+	return s.Audit.Append(ctx, statusChange(actorID, kycCase, status, reason))
+}
+
+func statusChange(actorID string, kycCase *models.KYCCase, status, reason string) *audit.Event {
+	data := map[string]string{"status": status, "case_id": kycCase.ID.Hex()}
+	if reason != "" {
+		data["reason"] = reason
+	}
+	return &audit.Event{
+		Type:        audit.EventKYCStatusChanged,
+		ActorID:     actorID,
+		SubjectType: audit.SubjectUser,
+		SubjectID:   kycCase.UserID.Hex(),
+		Data:        data,
+	}
+}
+
+// validSignature checks the X-KYC-Signature header: "sha256=" followed by the hex
+// HMAC-SHA256 of the raw body under the shared webhook secret.
+func (s *KYCService) validSignature(payload []byte, signature string) bool {
+	given, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), kycSignaturePrefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+	mac.Write(payload)
+	return hmac.Equal(given, mac.Sum(nil))
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+const (
+	LimitCodeCurrencyNotAllowed = "currency_not_allowed"
+	LimitCodeSingle             = "single_limit_exceeded"
+	LimitCodeDaily              = "daily_limit_exceeded"
+	LimitCodeMonthly            = "monthly_limit_exceeded"
+	LimitCodeBalance            = "balance_limit_exceeded"
+	LimitCodeRecipientBalance   = "recipient_balance_limit_exceeded"
+)
+
+// NoTierUnlocks is LimitError.RequiredTier when no tier would allow the operation.
+const NoTierUnlocks = -1
+
+// LimitError rejects an operation that the user's KYC tier does not allow. RequiredTier is
+// the lowest tier that would allow it, so clients can prompt for the right upgrade.
+type LimitError struct {
+	Code         string
+	Message      string
+	CurrentTier  int
+	RequiredTier int
+	Limit        *money.Money // nil for currency_not_allowed
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// TierLimits are the caps for one currency. A nil cap means unlimited.
+type TierLimits struct {
+	Single     *money.Money
+	Daily      *money.Money
+	Monthly    *money.Money
+	MaxBalance *money.Money
+}
+
+// KYCTier is a verification level and the limits it unlocks per currency. Currencies
+// missing from Limits cannot be used at that tier.
+type KYCTier struct {
+	Level  int
+	Name   string
+	Limits map[string]TierLimits
+}
+
+// LoadKYCTiers reads tier definitions from a JSON file shaped like kyc_tiers.example.json,
+// with amounts as decimal strings in major units. Unknown cap names and repeated levels
+// are errors, since a misspelt cap would otherwise quietly lift that limit.
+func LoadKYCTiers(path string) ([]KYCTier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Tiers []struct {
+			Level  int                          `json:"level"`
+			Name   string                       `json:"name"`
+			Limits map[string]map[string]string `json:"limits"`
+		} `json:"tiers"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	tiers := make([]KYCTier, 0, len(file.Tiers))
+	levels := map[int]bool{}
+	for _, t := range file.Tiers {
+		if levels[t.Level] {
+			return nil, fmt.Errorf("tier %d is defined more than once", t.Level)
+		}
+		levels[t.Level] = true
+
+		tier := KYCTier{Level: t.Level, Name: t.Name, Limits: map[string]TierLimits{}}
+		for currency, caps := range t.Limits {
+			currency = money.NormalizeCurrency(currency)
+			limits := TierLimits{}
+			targets := map[string]**money.Money{
+				"single":      &limits.Single,
+				"daily":       &limits.Daily,
+				"monthly":     &limits.Monthly,
+				"max_balance": &limits.MaxBalance,
+			}
+			for field, raw := range caps {
+				target, ok := targets[field]
+				if !ok {
+					return nil, fmt.Errorf("tier %d %s: unknown limit %q, want single, daily, monthly or max_balance", t.Level, currency, field)
+				}
+				if raw == "" {
+					continue
+				}
+				amount, err := money.Parse(raw, currency)
+				if err != nil {
+					return nil, fmt.Errorf("tier %d %s %s: %w", t.Level, currency, field, err)
+				}
+				*target = &amount
+			}
+			tier.Limits[currency] = limits
+		}
+		tiers = append(tiers, tier)
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Level < tiers[j].Level })
+	return tiers, nil
+}
+
+// LimitService enforces the per-tier caps on account opening, transfers and conversions.
+type LimitService struct {
+	UserRepo        repositories.UserRepository
+	TransactionRepo repositories.TransactionRepository
+	Clock           clock.Clock
+	tiers           []KYCTier
+}
+
+func NewLimitService(userRepo repositories.UserRepository, txRepo repositories.TransactionRepository, tiers []KYCTier) *LimitService {
+	return &LimitService{
+		UserRepo:        userRepo,
+		TransactionRepo: txRepo,
+		Clock:           clock.System,
+		tiers:           tiers,
+	}
+}
+
+// Tiers returns the configured tiers, lowest first.
+func (s *LimitService) Tiers() []KYCTier {
+	return s.tiers
+}
+
+// CheckAccountOpen makes sure the user's tier allows holding currency at all.
+func (s *LimitService) CheckAccountOpen(userID, currency string) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if _, ok := s.limitsFor(user.KYCTier, currency); ok {
+		return nil
+	}
+	return &LimitError{
+		Code:         LimitCodeCurrencyNotAllowed,
+		Message:      fmt.Sprintf("%s accounts are not available at your verification level", currency),
+		CurrentTier:  user.KYCTier,
+		RequiredTier: s.lowestTier(user.KYCTier, currency, func(TierLimits) bool { return true }),
+	}
+}
+
+// CheckOutgoing verifies that sending amount from the user's account fits the single,
+// daily and monthly caps. Volumes count completed transactions since midnight UTC and
+// since the first of the month UTC.
+func (s *LimitService) CheckOutgoing(ctx context.Context, user *models.User, from *models.Account, amount money.Money) error {
+	currency := amount.Currency
+	limits, ok := s.limitsFor(user.KYCTier, currency)
+	if !ok {
+		return s.CheckAccountOpen(user.ID.Hex(), currency)
+	}
+
+	if exceeds(limits.Single, amount.Amount) {
+		return s.limitError(user.KYCTier, currency, LimitCodeSingle, "amount exceeds the single transaction limit", limits.Single,
+			func(l TierLimits) bool { return !exceeds(l.Single, amount.Amount) })
+	}
+
+	now := s.Clock.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if limits.Daily != nil {
+		sent, err := s.TransactionRepo.SumOutgoing(ctx, from.ID, dayStart)
+		if err != nil {
+			return err
+		}
+		if exceeds(limits.Daily, sent+amount.Amount) {
+			return s.limitError(user.KYCTier, currency, LimitCodeDaily, "amount exceeds the daily limit", limits.Daily,
+				func(l TierLimits) bool { return !exceeds(l.Daily, sent+amount.Amount) })
+		}
+	}
+
+	if limits.Monthly != nil {
+		sent, err := s.TransactionRepo.SumOutgoing(ctx, from.ID, monthStart)
+		if err != nil {
+			return err
+		}
+		if exceeds(limits.Monthly, sent+amount.Amount) {
+			return s.limitError(user.KYCTier, currency, LimitCodeMonthly, "amount exceeds the monthly limit", limits.Monthly,
+				func(l TierLimits) bool { return !exceeds(l.Monthly, sent+amount.Amount) })
+		}
+	}
+	return nil
+}
+
+// CheckIncoming verifies that crediting amount keeps the owner's account under their
+// tier's balance cap. isOwn tells whether the caller owns the account, which only changes
+// the error code and message.
+func (s *LimitService) CheckIncoming(owner *models.User, to *models.Account, amount money.Money, isOwn bool) error {
+	currency := amount.Currency
+	code, message := LimitCodeBalance, "this would take your balance over the limit for your verification level"
+	if !isOwn {
+		code, message = LimitCodeRecipientBalance, "the recipient cannot receive this amount at their verification level"
+	}
+
+	limits, ok := s.limitsFor(owner.KYCTier, currency)
+	if !ok {
+		return &LimitError{Code: code, Message: message, CurrentTier: owner.KYCTier,
+			RequiredTier: s.lowestTier(owner.KYCTier, currency, func(TierLimits) bool { return true })}
+	}
+
+	after := to.Balance.Amount + amount.Amount
+	if exceeds(limits.MaxBalance, after) {
+		return s.limitError(owner.KYCTier, currency, code, message, limits.MaxBalance,
+			func(l TierLimits) bool { return !exceeds(l.MaxBalance, after) })
+	}
+	return nil
+}
+
+// CheckTransfer applies the sender's outgoing caps and the recipient's balance cap.
+func (s *LimitService) CheckTransfer(ctx context.Context, from, to *models.Account, amount money.Money) error {
+	sender, err := s.UserRepo.FindByID(from.UserID.Hex())
+	if err != nil {
+		return err
+	}
+	if err := s.CheckOutgoing(ctx, sender, from, amount); err != nil {
+		return err
+	}
+
+	recipient := sender
+	if to.UserID != from.UserID {
+		if recipient, err = s.UserRepo.FindByID(to.UserID.Hex()); err != nil {
+			return err
+		}
+	}
+	return s.CheckIncoming(recipient, to, amount, to.UserID == from.UserID)
+}
+
+// CheckConversion applies the outgoing caps to the sold amount and the balance cap to the
+// bought amount, both on the same user's accounts.
+func (s *LimitService) CheckConversion(ctx context.Context, from, to *models.Account, sell, buy money.Money) error {
+	user, err := s.UserRepo.FindByID(from.UserID.Hex())
+	if err != nil {
+		return err
+	}
+	if err := s.CheckOutgoing(ctx, user, from, sell); err != nil {
+		return err
+	}
+	return s.CheckIncoming(user, to, buy, true)
+}
+
+func (s *LimitService) limitsFor(level int, currency string) (TierLimits, bool) {
+	for _, tier := range s.tiers {
+		if tier.Level == level {
+			limits, ok := tier.Limits[currency]
+			return limits, ok
+		}
+	}
+	return TierLimits{}, false
+}
+
+// lowestTier finds the first tier above current whose limits for currency satisfy ok.
+func (s *LimitService) lowestTier(current int, currency string, ok func(TierLimits) bool) int {
+	for _, tier := range s.tiers {
+		if tier.Level <= current {
+			continue
+		}
+		if limits, found := tier.Limits[currency]; found && ok(limits) {
+			return tier.Level
+		}
+	}
+	return NoTierUnlocks
+}
+
+func (s *LimitService) limitError(current int, currency, code, message string, limit *money.Money, ok func(TierLimits) bool) *LimitError {
+	return &LimitError{
+		Code:         code,
+		Message:      message,
+		CurrentTier:  current,
+		RequiredTier: s.lowestTier(current, currency, ok),
+		Limit:        limit,
+	}
+}
+
+func exceeds(limit *money.Money, minorUnits int64) bool {
+	return limit != nil && minorUnits > limit.Amount
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/repositories/memory"
+)
+
+type limitFixture struct {
+	users    *memory.UserRepository
+	accounts *memory.AccountRepository
+	txs      *memory.TransactionRepository
+	limits   *LimitService
+	service  *AccountService
+}
+
+func newLimitFixture(t *testing.T) *limitFixture {
+	t.Helper()
+	tiers, err := LoadKYCTiers("../../kyc_tiers.example.json")
+	if err != nil {
+		t.Fatalf("LoadKYCTiers: %v", err)
+	}
+	f := &limitFixture{
+		users:    memory.NewUserRepo(clock.System),
+		accounts: memory.NewAccountRepo(clock.System),
+		txs:      memory.NewTransactionRepo(clock.System),
+	}
+	f.limits = NewLimitService(f.users, f.txs, tiers)
+	f.service = NewAccountService(f.accounts, nil, f.limits)
+	return f
+}
+
+func (f *limitFixture) user(t *testing.T, email string, tier int) *models.User {
+	t.Helper()
+	user, err := f.users.CreateUser(&models.User{FullName: email, Email: email})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := f.users.SetKYCTier(context.Background(), user.ID.Hex(), tier); err != nil {
+		t.Fatalf("SetKYCTier: %v", err)
+	}
+	user.KYCTier = tier
+	return user
+}
+
+func mustMoney(t *testing.T, amount, currency string) money.Money {
+	t.Helper()
+	m, err := money.Parse(amount, currency)
+	if err != nil {
+		t.Fatalf("money.Parse(%q): %v", amount, err)
+	}
+	return m
+}
+
+func TestCreateAccountChecksTierAndUniqueness(t *testing.T) {
+	f := newLimitFixture(t)
+	ctx := context.Background()
+	user := f.user(t, "ada@example.com", models.KYCTierEmail)
+
+	if _, err := f.service.CreateAccount(ctx, user.ID.Hex(), "usd"); err != nil {
+		t.Fatalf("USD account: %v", err)
+	}
+	if _, err := f.service.CreateAccount(ctx, user.ID.Hex(), "USD"); !errors.Is(err, repositories.ErrAccountExists) {
+		t.Fatalf("second USD account: got %v, want ErrAccountExists", err)
+	}
+
+	_, err := f.service.CreateAccount(ctx, user.ID.Hex(), "AED")
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Code != LimitCodeCurrencyNotAllowed || limitErr.RequiredTier != models.KYCTierID {
+		t.Fatalf("AED at tier 0: got %v", err)
+	}
+
+	accounts, err := f.service.ListAccounts(ctx, user.ID.Hex())
+	if err != nil || len(accounts) != 1 {
+		t.Fatalf("ListAccounts: %d accounts, %v", len(accounts), err)
+	}
+}
+
+func TestCheckTransferLimits(t *testing.T) {
+	f := newLimitFixture(t)
+	ctx := context.Background()
+	sender := f.user(t, "ada@example.com", models.KYCTierEmail)
+	recipient := f.user(t, "bob@example.com", models.KYCTierEmail)
+
+	from, err := f.service.CreateAccount(ctx, sender.ID.Hex(), "USD")
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	to, err := f.service.CreateAccount(ctx, recipient.ID.Hex(), "USD")
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		sentToday string
+		amount    string
+		balance   string
+		code      string
+	}{
+		{name: "within limits", amount: "50.00"},
+		{name: "single limit", amount: "150.00", code: LimitCodeSingle},
+		{name: "daily limit", sentToday: "200.00", amount: "60.00", code: LimitCodeDaily},
+		{name: "recipient balance", amount: "50.00", balance: "980.00", code: LimitCodeRecipientBalance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.txs = memory.NewTransactionRepo(clock.System)
+			f.limits.TransactionRepo = f.txs
+			if tt.sentToday != "" {
+				_, err := f.txs.Create(ctx, &models.Transaction{
+					FromAccount: from.ID,
+					Amount:      mustMoney(t, tt.sentToday, "USD"),
+					Status:      models.TransactionStatusCompleted,
+				})
+				if err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+			}
+			target := *to
+			if tt.balance != "" {
+				target.Balance = mustMoney(t, tt.balance, "USD")
+			}
+
+			err := f.limits.CheckTransfer(ctx, from, &target, mustMoney(t, tt.amount, "USD"))
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Code != tt.code {
+				t.Fatalf("got %v, want %s", err, tt.code)
+			}
+			if limitErr.RequiredTier != models.KYCTierID {
+				t.Fatalf("required tier %d, want %d", limitErr.RequiredTier, models.KYCTierID)
+			}
+		})
+	}
+}
+
+func TestLoadKYCTiersRejectsMistakes(t *testing.T) {
+	cases := map[string]string{
+		"misspelt cap":   `{"tiers": [{"level": 0, "limits": {"USD": {"single": "100.00", "dailly": "250.00"}}}]}`,
+		"repeated level": `{"tiers": [{"level": 0, "limits": {}}, {"level": 0, "limits": {}}]}`,
+	}
+	for name, contents := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tiers.json")
+			if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadKYCTiers(path); err == nil {
+				t.Fatal("LoadKYCTiers accepted the file")
+			}
+		})
+	}
+}
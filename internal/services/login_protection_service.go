@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/mailer"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+// LoginThrottledError is returned while a login must wait out a backoff delay or a lock.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // the account itself is locked rather than the attempt delayed
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "account temporarily locked after too many failed logins"
+	}
+	return "too many failed logins, try again later"
+}
+
+// LoginPolicy holds the brute-force thresholds from config.AuthConfig.
+type LoginPolicy struct {
+	FailureWindow      time.Duration // failures older than this are forgotten
+	BackoffAfter       int           // failures on an account before delays kick in
+	BackoffBase        time.Duration // first delay, doubled for every further failure
+	BackoffMax         time.Duration
+	LockoutThreshold   int // failures on an account that lock it
+	LockoutDuration    time.Duration
+	IPLockoutThreshold int // failures from one address, across accounts, that block it
+}
+
+type LoginProtectionService struct {
+	AttemptRepo repositories.LoginAttemptRepository
+	UserService *UserServices
+	Mailer      mailer.Mailer
+	Audit       *audit.Logger
+	Metrics     *metrics.Metrics
+	Clock       clock.Clock
+	policy      LoginPolicy
+}
+
+func NewLoginProtectionService(attemptRepo repositories.LoginAttemptRepository, userService *UserServices, mail mailer.Mailer, auditLog *audit.Logger, walletMetrics *metrics.Metrics, policy LoginPolicy) *LoginProtectionService {
+	return &LoginProtectionService{
+		AttemptRepo: attemptRepo,
+		UserService: userService,
+		Mailer:      mail,
+		Audit:       auditLog,
+		Metrics:     walletMetrics,
+		Clock:       clock.System,
+		policy:      policy,
+	}
+}
+
+// Accounts are tracked by email rather than user ID, so an address that is not registered
+// backs off and locks exactly like one that is and nobody can tell them apart.
+func loginAccountKey(email string) string { return "email:" + models.NormalizeEmail(email) }
+func loginIPKey(ip string) string         { return "ip:" + ip }
+func twoFactorKey(userID string) string   { return "2fa:" + userID }
+
+// Login checks the password like VerifyCredentials, but refuses to even try while the
+// account or client address is backing off or locked, and counts every failure.
+func (s *LoginProtectionService) Login(ctx context.Context, email, password, ip string) (*models.User, error) {
+	now := s.Clock.Now()
+
+	if err := s.checkIP(ctx, ip, now); err != nil {
+		return nil, s.countThrottled(err)
+	}
+	accountKey := loginAccountKey(email)
+	if err := s.checkAccount(ctx, accountKey, now); err != nil {
+		return nil, s.countThrottled(err)
+	}
+
+	user, err := s.UserService.VerifyCredentials(email, password)
+	if err == nil {
+		if err := s.AttemptRepo.Clear(ctx, accountKey); err != nil {
+			slog.ErrorContext(ctx, "Failed to clear login failures", "user_id", user.ID.Hex(), "error", err)
+		}
+		s.Audit.Record(ctx, audit.Event{
+			Type:        audit.EventLoginSucceeded,
+			ActorID:     user.ID.Hex(),
+			SubjectType: audit.SubjectUser,
+			SubjectID:   user.ID.Hex(),
+			IP:          ip,
+			Data:        map[string]string{"two_factor_pending": fmt.Sprint(user.TwoFactorEnabled)},
+		})
+		s.Metrics.Login(metrics.LoginSucceeded)
+		return user, nil
+	}
+	if !errors.Is(err, ErrInvalidCredentials) {
+		return nil, err
+	}
+
+	// only for the audit trail and the lockout notice; the response must not depend on it
+	account, _ := s.UserService.UserRepo.FindByEmail(email)
+	failure := audit.Event{Type: audit.EventLoginFailed, ActorID: audit.ActorAnonymous, IP: ip, Data: map[string]string{"email": email}}
+	if account != nil {
+		failure.SubjectType = audit.SubjectUser
+		failure.SubjectID = account.ID.Hex()
+	}
+	s.Audit.Record(ctx, failure)
+	s.Metrics.Login(metrics.LoginFailed)
+
+	if err := s.recordIPFailure(ctx, ip, now); err != nil {
+		return nil, err
+	}
+	if err := s.recordAccountFailure(ctx, accountKey, account, now); err != nil {
+		return nil, err
+	}
+	return nil, ErrInvalidCredentials
+}
+
+func (s *LoginProtectionService) countThrottled(err error) error {
+	var throttled *LoginThrottledError
+	if errors.As(err, &throttled) {
+		s.Metrics.Login(metrics.LoginThrottled)
+	}
+	return err
+}
+
+// CheckSecondFactor refuses to check a code while the user's wrong 2FA codes have them
+// backing off or locked, with the same thresholds as passwords.
+func (s *LoginProtectionService) CheckSecondFactor(ctx context.Context, userID string) error {
+	return s.countThrottled(s.checkAccount(ctx, twoFactorKey(userID), s.Clock.Now()))
+}
+
+// SecondFactorFailed counts a wrong TOTP or recovery code, locking at the threshold.
+func (s *LoginProtectionService) SecondFactorFailed(ctx context.Context, user *models.User) error {
+	return s.recordAccountFailure(ctx, twoFactorKey(user.ID.Hex()), user, s.Clock.Now())
+}
+
+// SecondFactorPassed forgets the user's wrong codes.
+func (s *LoginProtectionService) SecondFactorPassed(ctx context.Context, userID string) error {
+	return s.AttemptRepo.Clear(ctx, twoFactorKey(userID))
+}
+
+// Unlock lifts an account lock and forgets its failures, for passwords and 2FA codes alike.
+func (s *LoginProtectionService) Unlock(ctx context.Context, userID string) error {
+	user, err := s.UserService.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.AttemptRepo.Clear(ctx, loginAccountKey(user.Email)); err != nil {
+		return err
+	}
+	return s.AttemptRepo.Clear(ctx, twoFactorKey(userID))
+}
+
+func (s *LoginProtectionService) checkIP(ctx context.Context, ip string, now time.Time) error {
+	attempt, err := s.AttemptRepo.Find(ctx, loginIPKey(ip))
+	if err != nil || attempt == nil {
+		return err
+	}
+	if attempt.LockedUntil.After(now) {
+		return &LoginThrottledError{RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+func (s *LoginProtectionService) checkAccount(ctx context.Context, key string, now time.Time) error {
+	attempt, err := s.AttemptRepo.Find(ctx, key)
+	if err != nil || attempt == nil {
+		return err
+	}
+	if attempt.LockedUntil.After(now) {
+		return &LoginThrottledError{RetryAfter: attempt.LockedUntil.Sub(now), Locked: true}
+	}
+	if now.Sub(attempt.LastFailureAt) >= s.policy.FailureWindow {
+		return nil
+	}
+	if wait := attempt.LastFailureAt.Add(s.backoff(attempt.Failures)).Sub(now); wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// backoff is the delay owed after failures failed attempts: nothing up to BackoffAfter,
+// then BackoffBase doubling with each failure, capped at BackoffMax.
+func (s *LoginProtectionService) backoff(failures int) time.Duration {
+	if failures < s.policy.BackoffAfter {
+		return 0
+	}
+	delay := float64(s.policy.BackoffBase) * math.Pow(2, float64(failures-s.policy.BackoffAfter))
+	if delay > float64(s.policy.BackoffMax) {
+		return s.policy.BackoffMax
+	}
+	return time.Duration(delay)
+}
+
+func (s *LoginProtectionService) recordIPFailure(ctx context.Context, ip string, now time.Time) error {
+	key := loginIPKey(ip)
+	attempt, err := s.AttemptRepo.RecordFailure(ctx, key, now, s.policy.FailureWindow)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures >= s.policy.IPLockoutThreshold {
+		slog.WarnContext(ctx, "Blocking logins from address", "ip", ip, "failures", attempt.Failures)
+		return s.AttemptRepo.Lock(ctx, key, now.Add(s.policy.LockoutDuration))
+	}
+	return nil
+}
+
+// recordAccountFailure counts a failure against key and locks it at the threshold. user
+// is nil for an unregistered address, which locks all the same but has nobody to notify.
+func (s *LoginProtectionService) recordAccountFailure(ctx context.Context, key string, user *models.User, now time.Time) error {
+	attempt, err := s.AttemptRepo.RecordFailure(ctx, key, now, s.policy.FailureWindow)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures < s.policy.LockoutThreshold {
+		return nil
+	}
+
+	lockedUntil := now.Add(s.policy.LockoutDuration)
+	if err := s.AttemptRepo.Lock(ctx, key, lockedUntil); err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	s.Audit.Record(ctx, audit.Event{
+		Type:        audit.EventAccountLocked,
+		SubjectType: audit.SubjectUser,
+		SubjectID:   user.ID.Hex(),
+		Data:        map[string]string{"failures": fmt.Sprint(attempt.Failures), "locked_until": lockedUntil.UTC().Format(time.RFC3339)},
+	})
+
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been locked",
+		Body: fmt.Sprintf("Hi %s,\n\nWe locked your account after %d failed sign-in attempts. You can sign in again after %s.\n\nIf this was not you, reset your password as soon as the lock ends.\n",
+			user.FullName, attempt.Failures, lockedUntil.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send lockout notice", "user_id", user.ID.Hex(), "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/mailer"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+type PasswordResetService struct {
+	ResetRepo   repositories.PasswordResetRepository
+	UserService *UserServices
+	AuthService *AuthService
+	Mailer      mailer.Mailer
+	Audit       *audit.Logger
+	Clock       clock.Clock
+	baseURL     string
+	expiry      time.Duration
+	maxPerHour  int
+
+	pending sync.WaitGroup // background RequestReset work
+}
+
+func NewPasswordResetService(
+	resetRepo repositories.PasswordResetRepository,
+	userService *UserServices,
+	authService *AuthService,
+	mail mailer.Mailer,
+	auditLog *audit.Logger,
+	baseURL string,
+	expiry time.Duration,
+	maxPerHour int,
+) *PasswordResetService {
+	return &PasswordResetService{
+		ResetRepo:   resetRepo,
+		UserService: userService,
+		AuthService: authService,
+		Mailer:      mail,
+		Audit:       auditLog,
+		Clock:       clock.System,
+		baseURL:     baseURL,
+		expiry:      expiry,
+		maxPerHour:  maxPerHour,
+	}
+}
+
+// RequestReset emails a single-use reset link. The work happens in the background and
+// failures are only logged, so the caller answers at the same speed whether or not the
+// address is registered or the mail server is up. Requests over the hourly limit are
+// dropped quietly, so the endpoint cannot be used to discover accounts or flood an inbox.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) {
+	ctx = context.WithoutCancel(ctx)
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.sendReset(ctx, email); err != nil {
+			slog.ErrorContext(ctx, "Failed to send password reset email", "error", err)
+		}
+	}()
+}
+
+// Wait blocks until every reset requested so far has been handed to the mailer.
+func (s *PasswordResetService) Wait() {
+	s.pending.Wait()
+}
+
+func (s *PasswordResetService) sendReset(ctx context.Context, email string) error {
+	user, err := s.UserService.UserRepo.FindByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	recent, err := s.ResetRepo.CountSince(ctx, user.ID, s.Clock.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent >= int64(s.maxPerHour) {
+		slog.WarnContext(ctx, "Password reset rate limit reached", "user_id", user.ID.Hex())
+		return nil
+	}
+
+	rawToken, err := randomToken()
+	if err != nil {
+		return err
+	}
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: s.Clock.Now().Add(s.expiry),
+	}
+	if err := s.ResetRepo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.baseURL, url.QueryEscape(rawToken))
+	return s.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and works once.\n\n%s\n\nIf you did not ask for this, you can ignore this email.\n",
+			user.FullName, s.expiry, link),
+	})
+}
+
+// ResetPassword consumes the token, sets the new password and signs the user out everywhere.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, rawToken, newPassword string) error {
+	now := s.Clock.Now()
+	token, err := s.ResetRepo.Consume(ctx, hashToken(rawToken), now)
+	if err != nil {
+		return err
+	}
+
+	userID := token.UserID.Hex()
+	if err := s.UserService.SetPassword(userID, newPassword); err != nil {
+		return err
+	}
+	if err := s.ResetRepo.InvalidateForUser(ctx, token.UserID, now); err != nil {
+		return err
+	}
+	s.Audit.Record(ctx, audit.Event{
+		Type:        audit.EventPasswordReset,
+		ActorID:     userID,
+		SubjectType: audit.SubjectUser,
+		SubjectID:   userID,
+	})
+	return s.AuthService.LogoutAll(ctx, userID)
+}
@@ -1 +1,335 @@
 package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrRatesUnavailable = errors.New("exchange rates unavailable")
+	ErrRateNotFound     = errors.New("no exchange rate for currency")
+)
+
+// RateSnapshot holds the units of each currency that one unit of Base buys.
+type RateSnapshot struct {
+	Base      string    `json:"base"`
+	Rates     Rates     `json:"rates"`
+	FetchedAt time.Time `json:"-"` // when the provider published the rates
+}
+
+// Rates holds exact exchange rates by currency. They are read from JSON numbers or
+// decimal strings straight into big.Rat, so 0.1 stays exactly 1/10, and written as
+// numbers with up to rateDecimals places.
+type Rates map[string]*big.Rat
+
+const rateDecimals = 8
+
+func (r *Rates) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.Number
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	rates := make(Rates, len(raw))
+	for currency, value := range raw {
+		rate, ok := new(big.Rat).SetString(value.String())
+		if !ok {
+			return fmt.Errorf("invalid rate %q for %s", value, currency)
+		}
+		rates[currency] = rate
+	}
+	*r = rates
+	return nil
+}
+
+func (r Rates) MarshalJSON() ([]byte, error) {
+	out := make(map[string]json.Number, len(r))
+	for currency, rate := range r {
+		decimal := rate.FloatString(rateDecimals)
+		if strings.Contains(decimal, ".") {
+			decimal = strings.TrimRight(strings.TrimRight(decimal, "0"), ".")
+		}
+		out[currency] = json.Number(decimal)
+	}
+	return json.Marshal(out)
+}
+
+// Rate returns the exact cross rate to convert one unit of from into to.
+func (s *RateSnapshot) Rate(from, to string) (*big.Rat, error) {
+	fromRate, err := s.rat(from)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := s.rat(to)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+func (s *RateSnapshot) rat(currency string) (*big.Rat, error) {
+	if currency == s.Base {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := s.Rates[currency]
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w %s", ErrRateNotFound, currency)
+	}
+	return rate, nil
+}
+
+// rebase re-expresses the snapshot relative to another currency it contains.
+func (s *RateSnapshot) rebase(base string) (*RateSnapshot, error) {
+	if base == s.Base {
+		return s, nil
+	}
+	pivot, ok := s.Rates[base]
+	if !ok || pivot.Sign() <= 0 {
+		return nil, fmt.Errorf("%w %s", ErrRateNotFound, base)
+	}
+
+	rates := make(Rates, len(s.Rates))
+	rates[s.Base] = new(big.Rat).Inv(pivot)
+	for currency, rate := range s.Rates {
+		if currency != base {
+			rates[currency] = new(big.Rat).Quo(rate, pivot)
+		}
+	}
+	return &RateSnapshot{Base: base, Rates: rates, FetchedAt: s.FetchedAt}, nil
+}
+
+// RateProvider fetches the latest exchange rates relative to base.
+type RateProvider interface {
+	FetchRates(ctx context.Context, base string) (*RateSnapshot, error)
+}
+
+// ratesDocument is the shape of the HTTP API's answers and of the static file. Timestamp
+// is the Unix time at which the rates were published.
+type ratesDocument struct {
+	RateSnapshot
+	Timestamp int64 `json:"timestamp"`
+}
+
+// HTTPRateProvider calls an exchange-rate API that answers GET <url>?base=USD with
+// {"base":"USD","timestamp":1700000000,"rates":{"EUR":0.92,...}}.
+type HTTPRateProvider struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+func NewHTTPRateProvider(apiURL, apiKey string, timeout time.Duration) *HTTPRateProvider {
+	return &HTTPRateProvider{
+		URL:    apiURL,
+		APIKey: apiKey,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPRateProvider) FetchRates(ctx context.Context, base string) (*RateSnapshot, error) {
+	endpoint, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, err
+	}
+	query := endpoint.Query()
+	query.Set("base", base)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.APIKey != "" {
+		req.Header.Set("apikey", p.APIKey)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rate provider returned %s", resp.Status)
+	}
+
+	var doc ratesDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	// the age of rates is the age the provider gives them, not the time they reached us
+	if doc.Timestamp <= 0 {
+		return nil, errors.New("rate provider response has no timestamp")
+	}
+	doc.FetchedAt = time.Unix(doc.Timestamp, 0)
+	if doc.Base == "" {
+		doc.Base = base
+	}
+	return doc.rebase(base)
+}
+
+// StaticRateProvider serves rates from a JSON file in the same shape as the HTTP API,
+// for local development and tests without network access. Without a timestamp in the
+// file, the rates are as old as the file's last modification.
+type StaticRateProvider struct {
+	Path string
+}
+
+func NewStaticRateProvider(path string) *StaticRateProvider {
+	return &StaticRateProvider{Path: path}
+}
+
+func (p *StaticRateProvider) FetchRates(ctx context.Context, base string) (*RateSnapshot, error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc ratesDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc.FetchedAt = info.ModTime()
+	if doc.Timestamp > 0 {
+		doc.FetchedAt = time.Unix(doc.Timestamp, 0)
+	}
+	return doc.rebase(base)
+}
+
+// after a failed refresh the provider is left alone for rateRetryMin, doubling with every
+// further failure up to rateRetryMax
+const (
+	rateRetryMin = 5 * time.Second
+	rateRetryMax = 5 * time.Minute
+)
+
+// RateService caches provider rates for CacheDuration. If a refresh fails it keeps serving
+// the last good snapshot until that is maxAge old, and backs off before asking again.
+type RateService struct {
+	provider      RateProvider
+	baseCurrency  string
+	cacheDuration time.Duration
+	maxAge        time.Duration
+	Clock         clock.Clock
+
+	refresh singleflight.Group
+
+	mu       sync.Mutex
+	snapshot *RateSnapshot
+	failures int
+	retryAt  time.Time
+	lastErr  error
+}
+
+func NewRateService(provider RateProvider, baseCurrency string, cacheDuration time.Duration, maxAge time.Duration) *RateService {
+	return &RateService{
+		provider:      provider,
+		baseCurrency:  money.NormalizeCurrency(baseCurrency),
+		cacheDuration: cacheDuration,
+		maxAge:        maxAge,
+		Clock:         clock.System,
+	}
+}
+
+func (s *RateService) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// GetRates returns rates relative to the base currency, refreshing the cache when it is stale.
+// Concurrent callers share one provider call, and each waits for it only as long as its
+// own context allows before falling back to the cached snapshot.
+func (s *RateService) GetRates(ctx context.Context) (*RateSnapshot, error) {
+	s.mu.Lock()
+	snapshot, retryAt, lastErr := s.snapshot, s.retryAt, s.lastErr
+	s.mu.Unlock()
+
+	now := s.Clock.Now()
+	if snapshot != nil && now.Sub(snapshot.FetchedAt) < s.cacheDuration {
+		return snapshot, nil
+	}
+	if now.Before(retryAt) {
+		return s.fallback(snapshot, lastErr)
+	}
+
+	// the provider call outlives a caller that gives up, so the others still get its result
+	result := s.refresh.DoChan(s.baseCurrency, func() (any, error) {
+		return s.fetch(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return s.fallback(snapshot, res.Err)
+		}
+		return res.Val.(*RateSnapshot), nil
+	case <-ctx.Done():
+		return s.fallback(snapshot, ctx.Err())
+	}
+}
+
+func (s *RateService) fetch(ctx context.Context) (*RateSnapshot, error) {
+	fresh, err := s.provider.FetchRates(ctx, s.baseCurrency)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.failures++
+		s.retryAt = s.Clock.Now().Add(min(rateRetryMin<<min(s.failures-1, 16), rateRetryMax))
+		s.lastErr = err
+		return nil, err
+	}
+	s.snapshot, s.failures, s.retryAt, s.lastErr = fresh, 0, time.Time{}, nil
+	return fresh, nil
+}
+
+// fallback serves the last good snapshot while it is no older than maxAge.
+func (s *RateService) fallback(snapshot *RateSnapshot, err error) (*RateSnapshot, error) {
+	if snapshot != nil && s.Age(snapshot) <= s.maxAge {
+		return snapshot, nil
+	}
+	return nil, fmt.Errorf("%w: %v", ErrRatesUnavailable, err)
+}
+
+// Rate returns the cross rate to convert one unit of from into to.
+func (s *RateService) Rate(ctx context.Context, from, to string) (*big.Rat, *RateSnapshot, error) {
+	snapshot, err := s.GetRates(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rate, err := snapshot.Rate(money.NormalizeCurrency(from), money.NormalizeCurrency(to))
+	if err != nil {
+		return nil, nil, err
+	}
+	return rate, snapshot, nil
+}
+
+// Age reports how old a snapshot is by the service's clock.
+func (s *RateService) Age(snapshot *RateSnapshot) time.Duration {
+	return s.Clock.Now().Sub(snapshot.FetchedAt)
+}
+
+// LastUpdated reports when the cached rates were fetched; zero if never.
+func (s *RateService) LastUpdated() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshot == nil {
+		return time.Time{}
+	}
+	return s.snapshot.FetchedAt
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+)
+
+// countingRateProvider stamps snapshots with the fake clock and fails while err is set.
+type countingRateProvider struct {
+	clock   *clock.Fake
+	calls   atomic.Int32
+	err     error
+	release chan struct{} // when set, each fetch waits for it to be closed
+}
+
+func (p *countingRateProvider) FetchRates(ctx context.Context, base string) (*RateSnapshot, error) {
+	p.calls.Add(1)
+	if p.release != nil {
+		<-p.release
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &RateSnapshot{Base: base, Rates: Rates{"EUR": big.NewRat(9, 10)}, FetchedAt: p.clock.Now()}, nil
+}
+
+func newTestRateService(provider *countingRateProvider) *RateService {
+	s := NewRateService(provider, "USD", time.Hour, 6*time.Hour)
+	s.Clock = provider.clock
+	return s
+}
+
+func TestRatesServeStaleSnapshotUntilMaxAge(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC))
+	provider := &countingRateProvider{clock: clk}
+	s := newTestRateService(provider)
+	ctx := context.Background()
+
+	if _, err := s.GetRates(ctx); err != nil {
+		t.Fatalf("GetRates: %v", err)
+	}
+
+	provider.err = errors.New("provider down")
+	clk.Advance(2 * time.Hour)
+	snapshot, err := s.GetRates(ctx)
+	if err != nil {
+		t.Fatalf("GetRates during an outage: %v", err)
+	}
+	if age := s.Age(snapshot); age != 2*time.Hour {
+		t.Fatalf("served snapshot is %s old, want the cached one", age)
+	}
+
+	// backing off: no further provider calls until the retry is due
+	calls := provider.calls.Load()
+	for range 5 {
+		s.GetRates(ctx)
+	}
+	if got := provider.calls.Load(); got != calls {
+		t.Fatalf("provider called %d more times while backing off", got-calls)
+	}
+
+	clk.Advance(5 * time.Hour)
+	if _, err := s.GetRates(ctx); !errors.Is(err, ErrRatesUnavailable) {
+		t.Fatalf("rates 7h old: got %v, want ErrRatesUnavailable", err)
+	}
+
+	provider.err = nil
+	clk.Advance(rateRetryMax)
+	if _, err := s.GetRates(ctx); err != nil {
+		t.Fatalf("GetRates after the provider recovered: %v", err)
+	}
+}
+
+func TestRatesConcurrentRefreshCallsProviderOnce(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC))
+	provider := &countingRateProvider{clock: clk, release: make(chan struct{})}
+	s := newTestRateService(provider)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.GetRates(context.Background())
+			errs <- err
+		}()
+	}
+	for provider.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the mutex is not held during the fetch, so other readers are not stuck behind it
+	if got := s.LastUpdated(); !got.IsZero() {
+		t.Fatalf("LastUpdated = %v before any fetch finished", got)
+	}
+	close(provider.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("GetRates: %v", err)
+		}
+	}
+	if got := provider.calls.Load(); got != 1 {
+		t.Fatalf("provider called %d times, want 1", got)
+	}
+}
+
+func TestRatesCallerGivesUpOnSlowProvider(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC))
+	provider := &countingRateProvider{clock: clk}
+	s := newTestRateService(provider)
+	if _, err := s.GetRates(context.Background()); err != nil {
+		t.Fatalf("GetRates: %v", err)
+	}
+
+	provider.release = make(chan struct{})
+	defer close(provider.release)
+	clk.Advance(2 * time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.GetRates(ctx); err != nil {
+		t.Fatalf("GetRates with a slow provider: %v, want the cached snapshot", err)
+	}
+}
+
+func TestHTTPRatesAreAsOldAsTheProviderSays(t *testing.T) {
+	published := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	body := `{"base":"USD","timestamp":1740992400,"rates":{"EUR":0.92}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	provider := NewHTTPRateProvider(server.URL, "", time.Second)
+
+	snapshot, err := provider.FetchRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("FetchRates: %v", err)
+	}
+	if !snapshot.FetchedAt.Equal(published) {
+		t.Fatalf("FetchedAt = %v, want the published time %v", snapshot.FetchedAt, published)
+	}
+
+	body = `{"base":"USD","rates":{"EUR":0.92}}`
+	if _, err := provider.FetchRates(context.Background(), "USD"); err == nil {
+		t.Fatal("FetchRates accepted rates without a timestamp")
+	}
+}
+
+func TestStaticRatesAreAsOldAsTheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	write := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+	provider := NewStaticRateProvider(path)
+
+	write(`{"base":"USD","rates":{"EUR":0.92}}`)
+	snapshot, err := provider.FetchRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("FetchRates: %v", err)
+	}
+	if !snapshot.FetchedAt.Equal(modified) {
+		t.Fatalf("FetchedAt = %v, want the file's modification time %v", snapshot.FetchedAt, modified)
+	}
+
+	write(`{"base":"USD","timestamp":1740992400,"rates":{"EUR":0.92}}`)
+	snapshot, err = provider.FetchRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("FetchRates: %v", err)
+	}
+	if want := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC); !snapshot.FetchedAt.Equal(want) {
+		t.Fatalf("FetchedAt = %v, want the timestamp in the file %v", snapshot.FetchedAt, want)
+	}
+}
+
+func TestRatesDecodeAndRebaseExactly(t *testing.T) {
+	var snapshot RateSnapshot
+	body := `{"base":"USD","rates":{"EUR":"0.92","JPY":150.1,"GBP":0.1}}`
+	if err := json.Unmarshal([]byte(body), &snapshot); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got := snapshot.Rates["GBP"]; got.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Fatalf("0.1 decoded as %s", got)
+	}
+
+	rebased, err := snapshot.rebase("EUR")
+	if err != nil {
+		t.Fatalf("rebase: %v", err)
+	}
+	cases := []struct {
+		from, to string
+		want     *big.Rat
+	}{
+		{"EUR", "USD", big.NewRat(100, 92)},
+		{"EUR", "JPY", big.NewRat(15010, 92)},
+		{"GBP", "JPY", big.NewRat(1501, 1)},
+	}
+	for _, c := range cases {
+		got, err := rebased.Rate(c.from, c.to)
+		if err != nil {
+			t.Fatalf("Rate(%s, %s): %v", c.from, c.to, err)
+		}
+		if got.Cmp(c.want) != 0 {
+			t.Errorf("Rate(%s, %s) = %s, want exactly %s", c.from, c.to, got, c.want)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"rates":{"EUR":"cheap"}}`), &snapshot); err == nil {
+		t.Error("Unmarshal accepted a rate that is not a number")
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+)
+
+// RevocationService answers "has this token been revoked?" from a short-lived in-process
+// cache in front of Mongo. Revocations made on another instance are seen once the cached
+// answer expires, i.e. within cacheTTL.
+type RevocationService struct {
+	repo     repositories.RevocationRepository
+	cacheTTL time.Duration
+	Clock    clock.Clock
+
+	mu    sync.Mutex
+	cache map[string]cachedRevocation
+}
+
+type cachedRevocation struct {
+	revokedAt time.Time // zero when the key is not revoked
+	fetchedAt time.Time
+}
+
+func NewRevocationService(repo repositories.RevocationRepository, cacheTTL time.Duration) *RevocationService {
+	return &RevocationService{
+		repo:     repo,
+		cacheTTL: cacheTTL,
+		Clock:    clock.System,
+		cache:    map[string]cachedRevocation{},
+	}
+}
+
+func tokenKey(jti string) string   { return "jti:" + jti }
+func sessionKey(sid string) string { return "sid:" + sid }
+func userKey(userID string) string { return "user:" + userID }
+
+// Revoke records the revocation in Mongo and in the local cache straight away. The time
+// is kept to the second, the precision of a token's iat it is compared with.
+func (s *RevocationService) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	now := s.Clock.Now().Truncate(time.Second)
+	err := s.repo.Revoke(ctx, &models.Revocation{ID: key, RevokedAt: now, ExpiresAt: expiresAt})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cache[key] = cachedRevocation{revokedAt: now, fetchedAt: now}
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked reports whether the token or its session has been revoked, or all of the
+// user's tokens issued before issuedAt. Tokens from the second of a user-wide revocation
+// stay valid, so logging in again straight after it works; one issued earlier in that
+// same second does too, which is the most iat can tell apart.
+func (s *RevocationService) IsRevoked(ctx context.Context, jti, sid, userID string, issuedAt time.Time) (bool, error) {
+	keys := []string{tokenKey(jti), sessionKey(sid), userKey(userID)}
+	revokedAt, err := s.lookup(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+
+	if !revokedAt[tokenKey(jti)].IsZero() || !revokedAt[sessionKey(sid)].IsZero() {
+		return true, nil
+	}
+	cutoff := revokedAt[userKey(userID)]
+	return !cutoff.IsZero() && issuedAt.Before(cutoff), nil
+}
+
+func (s *RevocationService) lookup(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	now := s.Clock.Now()
+	result := make(map[string]time.Time, len(keys))
+	missing := []string{}
+
+	s.mu.Lock()
+	for _, key := range keys {
+		entry, ok := s.cache[key]
+		if ok && now.Sub(entry.fetchedAt) < s.cacheTTL {
+			result[key] = entry.revokedAt
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	found, err := s.repo.FindMany(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range missing {
+		s.cache[key] = cachedRevocation{fetchedAt: now}
+		result[key] = time.Time{}
+	}
+	for _, revocation := range found {
+		s.cache[revocation.ID] = cachedRevocation{revokedAt: revocation.RevokedAt, fetchedAt: now}
+		result[revocation.ID] = revocation.RevokedAt
+	}
+	s.evictExpired(now)
+	return result, nil
+}
+
+// evictExpired keeps the cache from growing without bound; callers hold s.mu.
+func (s *RevocationService) evictExpired(now time.Time) {
+	if len(s.cache) < 10000 {
+		return
+	}
+	for key, entry := range s.cache {
+		if now.Sub(entry.fetchedAt) >= s.cacheTTL {
+			delete(s.cache, key)
+		}
+	}
+}
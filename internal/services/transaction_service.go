@@ -1 +1,204 @@
-package services
\ No newline at end of file
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransferError is a business-rule rejection that clients can branch on by Code.
+type TransferError struct {
+	Code    string
+	Message string
+}
+
+func (e *TransferError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrInvalidAmount     = &TransferError{Code: "invalid_amount", Message: "amount must be greater than zero"}
+	ErrSameAccount       = &TransferError{Code: "same_account", Message: "cannot transfer to the same account"}
+	ErrInsufficientFunds = &TransferError{Code: "insufficient_funds", Message: "insufficient funds"}
+	ErrAccountInactive   = &TransferError{Code: "account_inactive", Message: "account is inactive"}
+	ErrCurrencyMismatch  = &TransferError{Code: "currency_mismatch", Message: "accounts hold different currencies"}
+	ErrRecipientNotFound = &TransferError{Code: "recipient_not_found", Message: "recipient account not found"}
+)
+
+type TransferRequest struct {
+	FromAccountID  string
+	ToAccountID    string
+	RecipientEmail string // alternative to ToAccountID: pays the recipient's account in the sender's currency
+	Amount         money.Money
+	Description    string
+}
+
+type TransactionService struct {
+	TransactionRepo repositories.TransactionRepository
+	AccountRepo     repositories.AccountRepository
+	UserRepo        repositories.UserRepository
+	Ledger          ledger.Ledger
+	Transactor      repositories.Transactor
+	Limits          *LimitService
+	Audit           *audit.Logger
+	Metrics         *metrics.Metrics
+}
+
+func NewTransactionService(
+	txRepo repositories.TransactionRepository,
+	accountRepo repositories.AccountRepository,
+	userRepo repositories.UserRepository,
+	walletLedger ledger.Ledger,
+	transactor repositories.Transactor,
+	limits *LimitService,
+	auditLog *audit.Logger,
+	walletMetrics *metrics.Metrics,
+) *TransactionService {
+	return &TransactionService{
+		TransactionRepo: txRepo,
+		AccountRepo:     accountRepo,
+		UserRepo:        userRepo,
+		Ledger:          walletLedger,
+		Transactor:      transactor,
+		Limits:          limits,
+		Audit:           auditLog,
+		Metrics:         walletMetrics,
+	}
+}
+
+// Transfer debits the sender, credits the receiver and records the transaction and its
+// audit event in one Mongo transaction, so either all of them land or none do.
+func (s *TransactionService) Transfer(ctx context.Context, userID string, req TransferRequest) (*models.Transaction, error) {
+	if !req.Amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	var created *models.Transaction
+	err := s.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		from, err := s.AccountRepo.FindByID(ctx, req.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if from.UserID.Hex() != userID {
+			return repositories.ErrAccountNotFound
+		}
+
+		to, err := s.resolveRecipient(ctx, from, req)
+		if err != nil {
+			return err
+		}
+		if from.ID == to.ID {
+			return ErrSameAccount
+		}
+		if !from.IsActive || !to.IsActive {
+			return ErrAccountInactive
+		}
+		if from.Currency != to.Currency || req.Amount.Currency != from.Currency {
+			return ErrCurrencyMismatch
+		}
+		if cmp, err := from.Balance.Cmp(req.Amount); err != nil || cmp < 0 {
+			return ErrInsufficientFunds
+		}
+		if err := s.Limits.CheckTransfer(ctx, from, to, req.Amount); err != nil {
+			return err
+		}
+
+		tx := &models.Transaction{
+			ID:          primitive.NewObjectID(),
+			Type:        models.TransactionTypeTransfer,
+			UserID:      from.UserID,
+			FromAccount: from.ID,
+			ToAccount:   to.ID,
+			Amount:      req.Amount,
+			Fee:         money.Zero(from.Currency),
+			Description: req.Description,
+			Status:      models.TransactionStatusCompleted,
+		}
+
+		postings, err := ledger.Transfer(from.ID, to.ID, req.Amount)
+		if err != nil {
+			return err
+		}
+		entry, err := s.Ledger.Post(ctx, &ledger.JournalEntry{
+			Reference:   tx.ID.Hex(),
+			Description: "p2p transfer",
+			Postings:    postings,
+		})
+		if err != nil {
+			return translateLedgerError(err)
+		}
+		tx.JournalEntryID = entry.ID
+
+		created, err = s.TransactionRepo.Create(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		// last, as the in-memory transactor cannot roll back earlier writes
+		return s.Audit.Append(ctx, &audit.Event{
+			Type:        audit.EventTransfer,
+			ActorID:     userID,
+			SubjectType: audit.SubjectTransaction,
+			SubjectID:   created.ID.Hex(),
+			Data: map[string]string{
+				"from_account": created.FromAccount.Hex(),
+				"to_account":   created.ToAccount.Hex(),
+				"amount":       created.Amount.String(),
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Metrics.Transfer(created.Amount.Currency, created.Amount.Amount)
+	return created, nil
+}
+
+func (s *TransactionService) resolveRecipient(ctx context.Context, from *models.Account, req TransferRequest) (*models.Account, error) {
+	if req.ToAccountID != "" {
+		to, err := s.AccountRepo.FindByID(ctx, req.ToAccountID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrAccountNotFound) {
+				return nil, ErrRecipientNotFound
+			}
+			return nil, err
+		}
+		if to.Type == models.AccountTypeSystem {
+			return nil, ErrRecipientNotFound
+		}
+		return to, nil
+	}
+
+	recipient, err := s.UserRepo.FindByEmail(req.RecipientEmail)
+	if err != nil {
+		return nil, ErrRecipientNotFound
+	}
+	to, err := s.AccountRepo.FindByUserAndCurrency(ctx, recipient.ID, from.Currency)
+	if err != nil {
+		if errors.Is(err, repositories.ErrAccountNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+	return to, nil
+}
+
+func translateLedgerError(err error) error {
+	switch {
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return ErrInsufficientFunds
+	case errors.Is(err, ledger.ErrCurrencyMismatch):
+		return ErrCurrencyMismatch
+	case errors.Is(err, ledger.ErrUnknownAccount):
+		return repositories.ErrAccountNotFound
+	}
+	return err
+}
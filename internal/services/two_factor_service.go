@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/totp"
+)
+
+const (
+	totpSkewSteps     = 1
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("start two-factor enrolment first")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorRequired       = errors.New("two-factor code required")
+)
+
+type TwoFactorService struct {
+	UserRepo   repositories.UserRepository
+	Protection *LoginProtectionService // counts wrong codes so they cannot be guessed
+	Clock      clock.Clock
+	issuer     string
+}
+
+func NewTwoFactorService(repo repositories.UserRepository, protection *LoginProtectionService, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		UserRepo:   repo,
+		Protection: protection,
+		Clock:      clock.System,
+		issuer:     issuer,
+	}
+}
+
+// Enroll generates a new secret that only becomes active once Activate confirms a code from it.
+func (s *TwoFactorService) Enroll(userID string) (string, string, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TwoFactorEnabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.UserRepo.SetPendingTOTPSecret(userID, secret); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.URI(s.issuer, user.Email, secret), nil
+}
+
+// Activate turns 2FA on after the user proves their app generates valid codes, and
+// returns the plain recovery codes. They are only ever shown this once.
+func (s *TwoFactorService) Activate(userID, code string) ([]string, error) {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPPendingSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := totp.Validate(user.TOTPPendingSecret, code, s.Clock.Now(), totpSkewSteps)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UserRepo.EnableTwoFactor(userID, user.TOTPPendingSecret, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns 2FA off; it needs a current code so a stolen session alone cannot do it.
+func (s *TwoFactorService) Disable(ctx context.Context, userID, code string) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.VerifyCode(ctx, user, code); err != nil {
+		return err
+	}
+	return s.UserRepo.DisableTwoFactor(userID)
+}
+
+// CheckSensitiveOperation verifies code for users who have 2FA on; users without 2FA pass.
+func (s *TwoFactorService) CheckSensitiveOperation(ctx context.Context, userID, code string) error {
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return nil
+	}
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+	return s.VerifyCode(ctx, user, code)
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code. Every code
+// works once only. Wrong codes back off and lock like wrong passwords, failing with a
+// *LoginThrottledError.
+func (s *TwoFactorService) VerifyCode(ctx context.Context, user *models.User, code string) error {
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.Protection.CheckSecondFactor(ctx, user.ID.Hex()); err != nil {
+		return err
+	}
+
+	err := s.verifyCode(user, code)
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		if err := s.Protection.SecondFactorFailed(ctx, user); err != nil {
+			return err
+		}
+	case err == nil:
+		if err := s.Protection.SecondFactorPassed(ctx, user.ID.Hex()); err != nil {
+			slog.ErrorContext(ctx, "Failed to clear two-factor failures", "user_id", user.ID.Hex(), "error", err)
+		}
+	}
+	return err
+}
+
+func (s *TwoFactorService) verifyCode(user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if step, ok := totp.Validate(user.TOTPSecret, code, s.Clock.Now(), totpSkewSteps); ok {
+		accepted, err := s.UserRepo.AdvanceTOTPStep(user.ID.Hex(), step)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	consumed, err := s.UserRepo.ConsumeRecoveryCode(user.ID.Hex(), hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))
+		code := raw[:4] + "-" + raw[4:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
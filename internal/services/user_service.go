@@ -3,21 +3,30 @@ package services
 import (
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
 	"github.com/samoray1998/fintech-wallet/internal/models"
 	"github.com/samoray1998/fintech-wallet/internal/repositories"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
 type UserServices struct {
 	UserRepo   repositories.UserRepository
+	Metrics    *metrics.Metrics
 	bcryptCost int
+
+	dummyOnce sync.Once
+	dummyHash []byte
 }
 
-func NewUserService(repo repositories.UserRepository, bcryptCost int) *UserServices {
+func NewUserService(repo repositories.UserRepository, walletMetrics *metrics.Metrics, bcryptCost int) *UserServices {
 	return &UserServices{
 		UserRepo:   repo,
+		Metrics:    walletMetrics,
 		bcryptCost: bcryptCost,
 	}
 }
@@ -27,7 +36,7 @@ func (s *UserServices) Register(user *models.User) (*models.User, error) {
 	existingUser, _ := s.UserRepo.FindByEmail(user.Email)
 
 	if existingUser != nil {
-		return nil, errors.New("email already registered")
+		return nil, repositories.ErrEmailTaken
 	}
 	// Input validation
 	if strings.TrimSpace(user.FullName) == "" {
@@ -47,7 +56,12 @@ func (s *UserServices) Register(user *models.User) (*models.User, error) {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	return s.UserRepo.CreateUser(user)
+	created, err := s.UserRepo.CreateUser(user)
+	if err != nil {
+		return nil, err
+	}
+	s.Metrics.Registration()
+	return created, nil
 }
 
 func (s *UserServices) GetUserByID(id string) (*models.User, error) {
@@ -63,7 +77,9 @@ func (s *UserServices) VerifyCredentials(email, plainPassword string) (*models.U
 
 	user, err := s.UserRepo.FindByEmail(email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		// spend as long on an unknown email as on a wrong password, so timing does not tell them apart
+		bcrypt.CompareHashAndPassword(s.unknownUserHash(), []byte(plainPassword))
+		return nil, ErrInvalidCredentials
 	}
 
 	storedHash := strings.TrimSpace(user.Password)
@@ -73,7 +89,7 @@ func (s *UserServices) VerifyCredentials(email, plainPassword string) (*models.U
 	err = bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(inputPassword))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return nil, errors.New("invalid credentials")
+			return nil, ErrInvalidCredentials
 		}
 		return nil, errors.New("internal server error")
 	}
@@ -82,7 +98,25 @@ func (s *UserServices) VerifyCredentials(email, plainPassword string) (*models.U
 
 }
 
+// unknownUserHash is a hash at the configured cost that no password is checked against
+// for real.
+func (s *UserServices) unknownUserHash() []byte {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("no such user"), s.bcryptCost)
+	})
+	return s.dummyHash
+}
+
 func (s *UserServices) UpdateKYCStatus(userID, status string) (*models.User, error) {
 	return s.UserRepo.UpdateKYCStatus(userID, status)
 
 }
+
+// SetPassword hashes the new password with the configured bcrypt cost and stores it.
+func (s *UserServices) SetPassword(userID, plainPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(plainPassword), s.bcryptCost)
+	if err != nil {
+		return err
+	}
+	return s.UserRepo.UpdateUserPassword(userID, string(hashedPassword))
+}
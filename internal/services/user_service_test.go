@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/repositories"
+	"github.com/samoray1998/fintech-wallet/internal/repositories/memory"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestUserService() *UserServices {
+	return NewUserService(memory.NewUserRepo(clock.System), metrics.New(), bcrypt.MinCost)
+}
+
+func TestRegisterHashesPassword(t *testing.T) {
+	s := newTestUserService()
+
+	user, err := s.Register(&models.User{FullName: "Ada Lovelace", Email: "ada@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.Password == "correct horse" {
+		t.Fatal("password stored in plain text")
+	}
+	if user.Role != models.RoleUser || user.KYCStatus != models.KYCStatusUnverified {
+		t.Fatalf("got role %q, kyc %q", user.Role, user.KYCStatus)
+	}
+
+	stored, err := s.GetUserByID(user.ID.Hex())
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if stored.Email != "ada@example.com" {
+		t.Fatalf("got email %q", stored.Email)
+	}
+}
+
+func TestRegisterRejectsDuplicateEmail(t *testing.T) {
+	s := newTestUserService()
+
+	if _, err := s.Register(&models.User{FullName: "Ada", Email: "ada@example.com", Password: "correct horse"}); err != nil {
+		t.Fatalf("first Register: %v", err)
+	}
+	if _, err := s.Register(&models.User{FullName: "Ada Again", Email: "ada@example.com", Password: "battery staple"}); !errors.Is(err, repositories.ErrEmailTaken) {
+		t.Fatalf("second registration with the same email: got %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestEmailsAreCaseInsensitive(t *testing.T) {
+	s := newTestUserService()
+
+	user, err := s.Register(&models.User{FullName: "Ada", Email: " Ada@Example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.Email != "ada@example.com" {
+		t.Fatalf("stored email %q, want it normalized", user.Email)
+	}
+	if _, err := s.Register(&models.User{FullName: "Ada Again", Email: "ADA@example.com", Password: "battery staple"}); !errors.Is(err, repositories.ErrEmailTaken) {
+		t.Fatalf("registration differing only in case: got %v, want ErrEmailTaken", err)
+	}
+	if _, err := s.VerifyCredentials("ADA@EXAMPLE.COM", "correct horse"); err != nil {
+		t.Fatalf("login with a differently cased email: %v", err)
+	}
+}
+
+func TestVerifyCredentials(t *testing.T) {
+	s := newTestUserService()
+	if _, err := s.Register(&models.User{FullName: "Ada", Email: "ada@example.com", Password: "correct horse"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if _, err := s.VerifyCredentials("ada@example.com", "correct horse"); err != nil {
+		t.Fatalf("valid password rejected: %v", err)
+	}
+	if _, err := s.VerifyCredentials("ada@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong password: got %v", err)
+	}
+	if _, err := s.VerifyCredentials("nobody@example.com", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("unknown email: got %v", err)
+	}
+}
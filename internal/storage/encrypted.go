@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+var ErrDecryptFailed = errors.New("blob could not be decrypted")
+
+// EncryptedBlobStore seals every blob with AES-256-GCM before handing it to the wrapped
+// store, so neither the disk nor the database ever holds plaintext. The blob key is bound
+// in as associated data, which stops a stored blob from being swapped in under another key.
+// Blobs are sealed in memory; callers limit their size.
+type EncryptedBlobStore struct {
+	inner BlobStore
+	aead  cipher.AEAD
+}
+
+// NewEncryptedBlobStore wraps inner using a 32-byte key.
+func NewEncryptedBlobStore(inner BlobStore, key []byte) (*EncryptedBlobStore, error) {
+	if len(key) != 32 {
+		return nil, errors.New("encryption key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedBlobStore{inner: inner, aead: aead}, nil
+}
+
+func (s *EncryptedBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(plaintext)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := s.aead.Seal(nonce, nonce, plaintext, []byte(key))
+	return s.inner.Put(ctx, key, bytes.NewReader(sealed))
+}
+
+func (s *EncryptedBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	rc, err := s.inner.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	sealed, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, ErrDecryptFailed
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return nil, ErrDecryptFailed
+	}
+	return io.NopCloser(bytes.NewReader(plaintext)), nil
+}
+
+func (s *EncryptedBlobStore) Delete(ctx context.Context, key string) error {
+	return s.inner.Delete(ctx, key)
+}
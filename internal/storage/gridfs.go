@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSBlobStore keeps blobs in a GridFS bucket, using the key as the file name, so
+// every instance sees the same files without a shared disk.
+type GridFSBlobStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSBlobStore(db *mongo.Database, bucketName string) (*GridFSBlobStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSBlobStore{bucket: bucket}, nil
+}
+
+// Put replaces any earlier blob stored under key.
+func (s *GridFSBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := s.Delete(ctx, key); err != nil {
+		return err
+	}
+	upload, err := s.bucket.OpenUploadStream(key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(upload, r); err != nil {
+		upload.Abort()
+		return err
+	}
+	return upload.Close()
+}
+
+func (s *GridFSBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+	if _, err := s.bucket.DownloadToStreamByName(key, &buf); err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
+
+func (s *GridFSBlobStore) Delete(ctx context.Context, key string) error {
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"filename": key})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err := s.bucket.DeleteContext(ctx, file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return err
+		}
+	}
+	return cursor.Err()
+}
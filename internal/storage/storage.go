@@ -0,0 +1,18 @@
+// Package storage keeps opaque blobs such as KYC documents behind a small interface so
+// the backend (local disk, GridFS) can be swapped without touching callers.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores blobs under caller-chosen keys. Keys use "/" as a separator.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,219 @@
+// Package testutil runs the real router in-process against in-memory stores, a fake
+// clock and fake providers, so tests can drive the HTTP API the way a client would.
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/controllers"
+	"github.com/samoray1998/fintech-wallet/internal/ledger"
+	"github.com/samoray1998/fintech-wallet/internal/metrics"
+	"github.com/samoray1998/fintech-wallet/internal/middlewares"
+	"github.com/samoray1998/fintech-wallet/internal/models"
+	"github.com/samoray1998/fintech-wallet/internal/money"
+	"github.com/samoray1998/fintech-wallet/internal/repositories/memory"
+	"github.com/samoray1998/fintech-wallet/internal/routes"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+	"github.com/samoray1998/fintech-wallet/internal/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Start is where the fake clock of every App begins.
+var Start = time.Date(2025, time.March, 3, 9, 0, 0, 0, time.UTC)
+
+const (
+	JWTSecret     = "test-jwt-secret"
+	WebhookSecret = "test-webhook-secret"
+	MetricsToken  = "test-metrics-token" // also shows /readyz check details
+	FundingName   = "funding"            // system account Fund pays from
+)
+
+// App is one wallet server with all of its state in memory. The fields give tests a way
+// to set up and inspect state that the API does not expose.
+type App struct {
+	Router *gin.Engine
+	Clock  *clock.Fake
+	KYC    *services.FakeKYCProvider
+	Rates  *FakeRateProvider
+	Mail   *Mailbox
+	Resets *services.PasswordResetService
+
+	Users    *memory.UserRepository
+	Accounts *memory.AccountRepository
+	Ledger   *memory.Ledger
+	Audit    *audit.Logger
+	AuditLog *AuditStore
+
+	t testing.TB
+}
+
+// NewApp wires the services exactly as main does, with the fakes in place of MongoDB,
+// SMTP and the KYC and rate providers. The KYC provider approves every applicant at once.
+func NewApp(t testing.TB) *App {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	clk := clock.NewFake(Start)
+	walletMetrics := metrics.New()
+
+	userRepo := memory.NewUserRepo(clk)
+	accountRepo := memory.NewAccountRepo(clk)
+	transactionRepo := memory.NewTransactionRepo(clk)
+	walletLedger := memory.NewLedger(clk, accountRepo)
+	fxQuoteRepo := memory.NewFXQuoteRepo(clk)
+	tokenRepo := memory.NewTokenRepo(clk)
+	revocationRepo := memory.NewRevocationRepo()
+	passwordResetRepo := memory.NewPasswordResetRepo(clk)
+	loginAttemptRepo := memory.NewLoginAttemptRepo()
+	adminActionRepo := memory.NewAdminActionRepo(clk)
+	kycCaseRepo := memory.NewKYCCaseRepo(clk)
+	idempotencyRepo := memory.NewIdempotencyRepo(clk)
+	transactor := memory.NewTransactor()
+	auditStore := &AuditStore{MemoryStore: audit.NewMemoryStore()}
+	auditLog := audit.NewLogger(auditStore)
+
+	mail := &Mailbox{}
+	kycProvider := services.NewFakeKYCProvider(models.KYCStatusVerified)
+	rateProvider := &FakeRateProvider{Clock: clk, Base: "USD", Rates: map[string]string{"USD": "1", "EUR": "0.92", "GBP": "0.79", "JPY": "150"}}
+
+	tiers, err := services.LoadKYCTiers(repoFile("kyc_tiers.example.json"))
+	if err != nil {
+		t.Fatalf("load KYC tiers: %v", err)
+	}
+	blobs, err := storage.NewDiskBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("create blob store: %v", err)
+	}
+
+	userService := services.NewUserService(userRepo, walletMetrics, bcrypt.MinCost)
+	revocationService := services.NewRevocationService(revocationRepo, time.Minute)
+	revocationService.Clock = clk
+	authService := services.NewAuthService(userRepo, tokenRepo, revocationService, JWTSecret, 15*time.Minute, 7*24*time.Hour, 5*time.Minute)
+	authService.Clock = clk
+	limitService := services.NewLimitService(userRepo, transactionRepo, tiers)
+	limitService.Clock = clk
+	accountService := services.NewAccountService(accountRepo, walletLedger, limitService)
+	accountService.Clock = clk
+	emailVerificationService := services.NewEmailVerificationService(userRepo, authService, mail, "http://wallet.test", 24*time.Hour, true)
+	loginProtectionService := services.NewLoginProtectionService(loginAttemptRepo, userService, mail, auditLog, walletMetrics, services.LoginPolicy{
+		FailureWindow:      15 * time.Minute,
+		BackoffAfter:       3,
+		BackoffBase:        time.Second,
+		BackoffMax:         time.Minute,
+		LockoutThreshold:   10,
+		LockoutDuration:    15 * time.Minute,
+		IPLockoutThreshold: 100,
+	})
+	loginProtectionService.Clock = clk
+	twoFactorService := services.NewTwoFactorService(userRepo, loginProtectionService, "Wallet Test")
+	twoFactorService.Clock = clk
+	kycService := services.NewKYCService(userRepo, kycCaseRepo, kycProvider, transactor, auditLog, walletMetrics, WebhookSecret)
+	kycDocumentService := services.NewKYCDocumentService(kycCaseRepo, userRepo, blobs, 1<<20)
+	passwordResetService := services.NewPasswordResetService(passwordResetRepo, userService, authService, mail, auditLog, "http://wallet.test", time.Hour, 3)
+	passwordResetService.Clock = clk
+	rateService := services.NewRateService(rateProvider, "USD", time.Hour, 24*time.Hour)
+	rateService.Clock = clk
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo, userRepo, walletLedger, transactor, limitService, auditLog, walletMetrics)
+	fxService := services.NewFXService(fxQuoteRepo, accountRepo, transactionRepo, rateService, walletLedger, transactor, limitService, auditLog, walletMetrics, 50, time.Minute, 2*time.Hour)
+	fxService.Clock = clk
+	healthService := services.NewHealthService(5*time.Second,
+		services.HealthCheck{Name: "rates", Timeout: time.Second, Check: services.RatesFreshnessCheck(rateService, 6*time.Hour)},
+		services.HealthCheck{Name: "kyc_provider", Timeout: time.Second, Check: kycProvider.Ping},
+	)
+	healthService.Clock = clk
+	adminService := services.NewAdminService(userRepo, accountRepo, transactionRepo, adminActionRepo, kycService, transactor, auditLog)
+
+	authMiddleware := middlewares.NewAuthMiddleware(authService, twoFactorService, emailVerificationService)
+	router, err := routes.SetupRouter(authMiddleware,
+		middlewares.NewAdminAuditMiddleware(adminService),
+		middlewares.NewMetricsMiddleware(walletMetrics, MetricsToken),
+		middlewares.NewRateLimiter(middlewares.NewMemoryRateLimitStore()),
+		middlewares.NewIdempotencyMiddleware(idempotencyRepo),
+		controllers.NewAuthController(authService, userService, twoFactorService, emailVerificationService, loginProtectionService),
+		controllers.NewUserController(userService),
+		controllers.NewAccountController(accountService),
+		controllers.NewTransactionController(transactionService),
+		controllers.NewRateController(rateService),
+		controllers.NewFXController(fxService),
+		controllers.NewPasswordController(passwordResetService),
+		controllers.NewAdminController(adminService, kycService, kycDocumentService, loginProtectionService),
+		controllers.NewKYCController(kycService, kycDocumentService, 1<<20),
+		controllers.NewHealthController(healthService, MetricsToken),
+		middlewares.RateLimitPolicies{
+			Default: middlewares.RateLimitPolicy{Name: "default", Requests: 10000, Per: time.Minute, Burst: 10000},
+			Auth:    middlewares.RateLimitPolicy{Name: "auth", Requests: 10000, Per: time.Minute, Burst: 10000},
+		},
+		nil)
+	if err != nil {
+		t.Fatalf("router: %v", err)
+	}
+
+	return &App{
+		Router:   router,
+		Clock:    clk,
+		KYC:      kycProvider,
+		Rates:    rateProvider,
+		Mail:     mail,
+		Resets:   passwordResetService,
+		Users:    userRepo,
+		Accounts: accountRepo,
+		Ledger:   walletLedger,
+		Audit:    auditLog,
+		AuditLog: auditStore,
+		t:        t,
+	}
+}
+
+// Fund credits an account from the funding system account. The API has no deposit
+// route, so this is how tests put money into wallets.
+func (a *App) Fund(accountID string, amount string, currency string) {
+	a.t.Helper()
+	ctx := context.Background()
+
+	value, err := money.Parse(amount, currency)
+	if err != nil {
+		a.t.Fatalf("fund: %v", err)
+	}
+	to, err := primitive.ObjectIDFromHex(accountID)
+	if err != nil {
+		a.t.Fatalf("fund: %v", err)
+	}
+	funding, err := a.Ledger.SystemAccount(ctx, FundingName, value.Currency)
+	if err != nil {
+		a.t.Fatalf("fund: %v", err)
+	}
+	postings, err := ledger.Transfer(funding.ID, to, value)
+	if err != nil {
+		a.t.Fatalf("fund: %v", err)
+	}
+	_, err = a.Ledger.Post(ctx, &ledger.JournalEntry{
+		Reference:   "test-funding",
+		Description: "test funding",
+		Postings:    postings,
+	})
+	if err != nil {
+		a.t.Fatalf("fund %s: %v", accountID, err)
+	}
+}
+
+// SetRole changes a user's role directly, e.g. to get a staff member for admin routes.
+func (a *App) SetRole(userID, role string) {
+	a.t.Helper()
+	if err := a.Users.SetRole(context.Background(), userID, role); err != nil {
+		a.t.Fatalf("set role: %v", err)
+	}
+}
+
+// repoFile resolves a path relative to the repository root, wherever the test runs from.
+func repoFile(name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", name)
+}
@@ -0,0 +1,143 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/samoray1998/fintech-wallet/internal/audit"
+	"github.com/samoray1998/fintech-wallet/internal/clock"
+	"github.com/samoray1998/fintech-wallet/internal/mailer"
+	"github.com/samoray1998/fintech-wallet/internal/services"
+)
+
+// Mailbox keeps every message sent instead of delivering it. Err makes every send fail.
+type Mailbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	Err      error
+}
+
+func (m *Mailbox) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// SetError makes every send from now on fail with err, or succeed again when err is nil.
+func (m *Mailbox) SetError(err error) {
+	m.mu.Lock()
+	m.Err = err
+	m.mu.Unlock()
+}
+
+// Last returns the most recent message sent to to.
+func (m *Mailbox) Last(to string) (mailer.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return mailer.Message{}, false
+}
+
+var linkToken = regexp.MustCompile(`[?&]token=([^\s&]+)`)
+
+// LinkToken returns the token from the link in the most recent message sent to to, such
+// as an email verification or password reset link.
+func (m *Mailbox) LinkToken(to string) (string, bool) {
+	msg, ok := m.Last(to)
+	if !ok {
+		return "", false
+	}
+	match := linkToken.FindStringSubmatch(msg.Body)
+	if match == nil {
+		return "", false
+	}
+	token, err := url.QueryUnescape(match[1])
+	return token, err == nil
+}
+
+// FakeRateProvider serves fixed decimal rates stamped with the fake clock, less Age, so
+// rate freshness follows the clock too. Err makes every fetch fail.
+type FakeRateProvider struct {
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	Base  string
+	Rates map[string]string
+	Age   time.Duration // how long before the fetch the rates were published
+	Err   error
+}
+
+func (p *FakeRateProvider) FetchRates(ctx context.Context, base string) (*services.RateSnapshot, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return nil, p.Err
+	}
+	rates := make(services.Rates, len(p.Rates))
+	for currency, value := range p.Rates {
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid rate %q for %s", value, currency)
+		}
+		rates[currency] = rate
+	}
+	return &services.RateSnapshot{Base: p.Base, Rates: rates, FetchedAt: p.Clock.Now().Add(-p.Age)}, nil
+}
+
+// SetRate changes one rate for fetches from now on.
+func (p *FakeRateProvider) SetRate(currency, rate string) {
+	p.mu.Lock()
+	p.Rates[currency] = rate
+	p.mu.Unlock()
+}
+
+// SetAge makes fetches from now on return rates published age ago.
+func (p *FakeRateProvider) SetAge(age time.Duration) {
+	p.mu.Lock()
+	p.Age = age
+	p.mu.Unlock()
+}
+
+// SetError makes every fetch from now on fail with err, or succeed again when err is nil.
+func (p *FakeRateProvider) SetError(err error) {
+	p.mu.Lock()
+	p.Err = err
+	p.mu.Unlock()
+}
+
+// AuditStore is an audit.MemoryStore whose appends fail while Err is set.
+type AuditStore struct {
+	*audit.MemoryStore
+	mu  sync.Mutex
+	Err error
+}
+
+func (s *AuditStore) Append(ctx context.Context, event *audit.Event) error {
+	s.mu.Lock()
+	err := s.Err
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.MemoryStore.Append(ctx, event)
+}
+
+// SetError makes every append from now on fail with err, or succeed again when err is nil.
+func (s *AuditStore) SetError(err error) {
+	s.mu.Lock()
+	s.Err = err
+	s.mu.Unlock()
+}